@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/chanseok/slackExtract/internal/slack/fakeslack"
+)
+
+// fake-slack serves a sample workspace through a fake Slack Web API so the
+// download pipeline can be run end to end without a real cookie or token.
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8765", "Address to listen on")
+	rateLimit := flag.Int("rate-limit", 0, "Answer the first N conversations.history calls with HTTP 429")
+	pageSize := flag.Int("page-size", 0, "Items per page (default 100)")
+	flag.Parse()
+
+	server := fakeslack.New(fakeslack.SampleWorkspace())
+	if *pageSize > 0 {
+		server.PageSize = *pageSize
+	}
+	if *rateLimit > 0 {
+		server.RateLimit("conversations.history", *rateLimit, 1)
+	}
+
+	fmt.Printf("Fake Slack API listening on http://%s\n", *addr)
+	fmt.Println("Point slack-extract at it with:")
+	fmt.Printf("  SLACK_API_URL=http://%s/api/ SLACK_USER_TOKEN=%s SLACK_DS_COOKIE=xoxd-fake\n", *addr, fakeslack.SampleToken)
+	log.Fatal(http.ListenAndServe(*addr, server.Handler()))
+}
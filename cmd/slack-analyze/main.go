@@ -1,8 +1,11 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
 
@@ -10,6 +13,7 @@ import (
 
 	"github.com/chanseok/slackExtract/internal/config"
 	"github.com/chanseok/slackExtract/internal/llm"
+	"github.com/chanseok/slackExtract/internal/mdformat"
 	"github.com/chanseok/slackExtract/internal/meta"
 )
 
@@ -53,8 +57,10 @@ func main() {
 
 	// Initialize MetaManager
 	var metaManager *meta.Manager
+	var signals *signalSource
 	exportRoot := findExportRoot(os.Args[1])
 	if exportRoot != "" {
+		signals = newSignalSource(exportRoot)
 		var err error
 		metaManager, err = meta.NewManager(exportRoot)
 		if err != nil {
@@ -64,11 +70,19 @@ func main() {
 		}
 	}
 
+	// Ctrl+C stops the running request; finished reports are kept
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	// Process each file
 	for _, arg := range os.Args[1:] {
-		if err := processArg(arg, analyzer, metaManager); err != nil {
+		if err := processArg(ctx, arg, analyzer, metaManager, signals); err != nil {
 			fmt.Printf("Error processing %s: %v\n", arg, err)
 		}
+		if ctx.Err() != nil {
+			fmt.Println("Cancelled.")
+			break
+		}
 	}
 
 	if metaManager != nil {
@@ -78,7 +92,7 @@ func main() {
 	}
 }
 
-func processArg(path string, analyzer *llm.ChannelAnalyzer, mm *meta.Manager) error {
+func processArg(ctx context.Context, path string, analyzer *llm.ChannelAnalyzer, mm *meta.Manager, signals *signalSource) error {
 	info, err := os.Stat(path)
 	if err != nil {
 		return err
@@ -90,9 +104,12 @@ func processArg(path string, analyzer *llm.ChannelAnalyzer, mm *meta.Manager) er
 			return err
 		}
 		for _, entry := range entries {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
 				fullPath := filepath.Join(path, entry.Name())
-				if err := analyzeFile(fullPath, analyzer, mm); err != nil {
+				if err := analyzeFile(ctx, fullPath, analyzer, mm, signals); err != nil {
 					fmt.Printf("Error analyzing %s: %v\n", fullPath, err)
 				}
 			}
@@ -100,7 +117,7 @@ func processArg(path string, analyzer *llm.ChannelAnalyzer, mm *meta.Manager) er
 		return nil
 	}
 
-	return analyzeFile(path, analyzer, mm)
+	return analyzeFile(ctx, path, analyzer, mm, signals)
 }
 
 func findExportRoot(path string) string {
@@ -128,7 +145,7 @@ func findExportRoot(path string) string {
 	return ""
 }
 
-func analyzeFile(filePath string, analyzer *llm.ChannelAnalyzer, mm *meta.Manager) error {
+func analyzeFile(ctx context.Context, filePath string, analyzer *llm.ChannelAnalyzer, mm *meta.Manager, signals *signalSource) error {
 	// Extract channel name from filename
 	base := filepath.Base(filePath)
 	channelName := strings.TrimSuffix(base, ".md")
@@ -152,10 +169,34 @@ func analyzeFile(filePath string, analyzer *llm.ChannelAnalyzer, mm *meta.Manage
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	// The channel ID comes from the front matter, since the file name of a
+	// DM or a renamed channel need not match its name in the index
+	channelID, first, last := "", "", ""
+	if fm, err := mdformat.ReadFrontMatter(bytes.NewReader(content)); err == nil && fm != nil {
+		channelID, first, last = fm.ChannelID, fm.FirstMessageTS, fm.LastMessageTS
+	}
+	if channelID == "" && mm != nil {
+		if ch, exists := mm.GetChannelByName(channelName); exists {
+			channelID = ch.ID
+		}
+	}
+
+	// Reactions from the raw store feed the topic importance score; only
+	// those on the messages of the file are read
+	var reactions []llm.ReactionSignal
+	if channelID != "" {
+		if reactions, err = signals.reactions(channelID, first, last); err != nil {
+			fmt.Printf("  Warning: Analyzing without reactions: %v\n", err)
+		}
+	}
+	if len(reactions) > 0 {
+		fmt.Printf("  👍 Using reactions on %d messages\n", len(reactions))
+	}
+
 	fmt.Println("  🔍 Extracting topics...")
 	
 	// Perform analysis
-	result, err := analyzer.AnalyzeChannel(channelName, string(content))
+	result, err := analyzer.AnalyzeChannel(ctx, channelName, string(content), reactions)
 	if err != nil {
 		return fmt.Errorf("analysis failed: %w", err)
 	}
@@ -176,16 +217,12 @@ func analyzeFile(filePath string, analyzer *llm.ChannelAnalyzer, mm *meta.Manage
 
 	// Update metadata if manager is available
 	if mm != nil {
-		// Find channel by name
-		ch, exists := mm.GetChannelByName(channelName)
-		var channelID string
-		
-		if exists {
-			channelID = ch.ID
-		} else {
+		if _, exists := mm.GetChannel(channelID); !exists {
 			// If channel not found (e.g. manually exported or index missing), use channelName as ID
-			// This ensures we can still track analysis metadata
-			channelID = channelName
+			// unless the front matter names it. This ensures we can still track analysis metadata
+			if channelID == "" {
+				channelID = channelName
+			}
 			mm.EnsureChannel(channelID, channelName)
 			fmt.Printf("Info: Added %s to metadata index (ID: %s)\n", channelName, channelID)
 		}
@@ -269,47 +306,17 @@ type ChannelStats struct {
 }
 
 func calculateStats(content string) ChannelStats {
-	stats := ChannelStats{}
-	
-	// Regex for Date Header: ## 📅 2025-06-17
-	// Regex for Message Header: ### Name - 17:14:01
-	
-	lines := strings.Split(content, "\n")
-	var currentDate string
-	var dates []string
-	dateCounts := make(map[string]int)
-	
-	for _, line := range lines {
-		if strings.HasPrefix(line, "## 📅 ") {
-			currentDate = strings.TrimPrefix(line, "## 📅 ")
-			currentDate = strings.TrimSpace(currentDate)
-		} else if strings.HasPrefix(line, "### ") && strings.Contains(line, " - ") {
-			// It's a message header
-			stats.TotalMessages++
-			if currentDate != "" {
-				dates = append(dates, currentDate)
-				dateCounts[currentDate]++
-			}
-		}
-	}
+	parsed, _ := mdformat.Parse(strings.NewReader(content))
+	stats := ChannelStats{TotalMessages: parsed.Messages}
 
-	if len(dates) > 0 {
-		stats.StartDate = dates[0]
-		stats.EndDate = dates[len(dates)-1]
+	if days := parsed.Days(); len(days) > 0 {
+		stats.StartDate = days[0]
+		stats.EndDate = days[len(days)-1]
 	}
 
-	// Find Peak Period (Day with most messages)
-	maxCount := 0
-	peakDate := ""
-	for date, count := range dateCounts {
-		if count > maxCount {
-			maxCount = count
-			peakDate = date
-		}
-	}
-	
-	if peakDate != "" {
-		stats.PeakPeriod = fmt.Sprintf("%s (%d messages)", peakDate, maxCount)
+	// Peak Period: the day with the most messages
+	if peakDate, count := parsed.PeakDay(); peakDate != "" {
+		stats.PeakPeriod = fmt.Sprintf("%s (%d messages)", peakDate, count)
 	}
 
 	return stats
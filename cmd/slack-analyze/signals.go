@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/config"
+	"github.com/chanseok/slackExtract/internal/export"
+	"github.com/chanseok/slackExtract/internal/llm"
+	"github.com/chanseok/slackExtract/internal/slack"
+	"github.com/chanseok/slackExtract/internal/store"
+	slackgo "github.com/slack-go/slack"
+)
+
+// excerptLength is the number of characters of message text quoted per signal
+const excerptLength = 100
+
+// signalSource reads structured signals (currently reactions) from the raw
+// message store next to the exported Markdown
+type signalSource struct {
+	store   *store.Store
+	userMap map[string]string
+}
+
+// newSignalSource opens the raw store of an export directory
+func newSignalSource(exportRoot string) *signalSource {
+	users, _ := slack.LoadUserDirectory()
+	return &signalSource{
+		store:   store.New(exportRoot),
+		userMap: export.UserNames(users, export.NewOptions(config.LoadLocal())),
+	}
+}
+
+// errSignalsDone stops going through the store once the wanted messages are read
+var errSignalsDone = errors.New("signals done")
+
+// reactions returns the reactions on the messages of a channel whose ts lies
+// between first and last (both optional), and on their replies. The store is
+// read a month at a time and no further than the message at last. It returns
+// nil if the channel has no raw data.
+func (s *signalSource) reactions(channelID, first, last string) ([]llm.ReactionSignal, error) {
+	if s == nil || !s.store.Exists(channelID) {
+		return nil, nil
+	}
+	from, to := tsTime(first), tsTime(last)
+
+	var signals []llm.ReactionSignal
+	err := s.store.Each(channelID, func(msg slack.Message) error {
+		t := tsTime(msg.Timestamp)
+		if !to.IsZero() && t.After(to) {
+			return errSignalsDone
+		}
+		if !t.Before(from) {
+			for _, m := range append([]slackgo.Message{msg.Message}, msg.Replies...) {
+				if len(m.Reactions) == 0 || msg.RevisionOf(m.Timestamp).Deleted {
+					continue
+				}
+				signals = append(signals, s.reactionSignal(m))
+			}
+		}
+		// Later months are not read once the last wanted message is found
+		if !to.IsZero() && t.Equal(to) {
+			return errSignalsDone
+		}
+		return nil
+	})
+	if err != nil && err != errSignalsDone {
+		return nil, fmt.Errorf("failed to read reactions: %w", err)
+	}
+	return signals, nil
+}
+
+// tsTime returns the time of a Slack ts, zero if it is empty or invalid
+func tsTime(ts string) time.Time {
+	t, err := slack.ParseTimestamp(ts)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func (s *signalSource) reactionSignal(m slackgo.Message) llm.ReactionSignal {
+	signal := llm.ReactionSignal{
+		Author:    m.User,
+		Reactions: make(map[string]int, len(m.Reactions)),
+	}
+	if name, ok := s.userMap[m.User]; ok && name != "" {
+		signal.Author = name
+	} else if m.Username != "" {
+		signal.Author = m.Username
+	}
+	if t, err := slack.ParseTimestamp(m.Timestamp); err == nil {
+		signal.Time = t.Format("2006-01-02 15:04:05")
+	}
+
+	text := strings.Join(strings.Fields(export.CleanSlackText(m.Text, s.userMap, nil)), " ")
+	if runes := []rune(text); len(runes) > excerptLength {
+		text = string(runes[:excerptLength]) + "…"
+	}
+	signal.Excerpt = text
+
+	for _, r := range m.Reactions {
+		signal.Reactions[r.Name] += r.Count
+		signal.Total += r.Count
+	}
+	return signal
+}
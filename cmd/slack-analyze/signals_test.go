@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	"github.com/chanseok/slackExtract/internal/store"
+	slackgo "github.com/slack-go/slack"
+)
+
+func reacted(ts, text string, count int) slack.Message {
+	msg := slack.Message{Message: slackgo.Message{Msg: slackgo.Msg{Timestamp: ts, Text: text, User: "U1"}}}
+	if count > 0 {
+		msg.Reactions = []slackgo.ItemReaction{{Name: "+1", Count: count}}
+	}
+	return msg
+}
+
+func TestReactions(t *testing.T) {
+	dir := t.TempDir()
+	raw := store.New(dir)
+	err := raw.Append("C1", []slack.Message{
+		reacted("1700000000.000100", "november", 1), // 2023-11-14
+		reacted("1700000100.000100", "quiet", 0),
+		reacted("1701500000.000100", "december", 3), // 2023-12-02
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &signalSource{store: raw, userMap: map[string]string{"U1": "Alice"}}
+
+	tests := []struct {
+		name        string
+		first, last string
+		want        []string
+	}{
+		{"whole channel", "", "", []string{"november", "december"}},
+		{"from december", "1701500000.000100", "", []string{"december"}},
+		{"up to november", "", "1700000100.000100", []string{"november"}},
+		{"no messages", "1700000050.000100", "1700000100.000100", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signals, err := s.reactions("C1", tt.first, tt.last)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, sig := range signals {
+				got = append(got, sig.Excerpt)
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) || (len(got) > 1 && got[1] != tt.want[1]) {
+				t.Errorf("reactions on %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got, err := s.reactions("C2", "", ""); got != nil || err != nil {
+		t.Errorf("channel without raw data = %v, %v, want nothing", got, err)
+	}
+
+	// A damaged later month is not read when the wanted messages come before it
+	segment := filepath.Join(raw.Path("C1"), "2023-12.jsonl")
+	if err := os.WriteFile(segment, []byte("[\"not a record\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.reactions("C1", "", "1700000100.000100"); err != nil {
+		t.Errorf("reading up to november: %v", err)
+	}
+	if _, err := s.reactions("C1", "", ""); err == nil {
+		t.Error("reading the damaged month returned no error")
+	}
+}
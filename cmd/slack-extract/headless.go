@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/chanseok/slackExtract/internal/downloader"
+	"github.com/chanseok/slackExtract/internal/manager"
+	slackgo "github.com/slack-go/slack"
+)
+
+// headlessOptions holds the flags for non-interactive mode
+type headlessOptions struct {
+	Channels     string    // Comma-separated channel names or IDs
+	ChannelRegex string    // Regular expression matched against channel names
+	Folder       string    // Subfolder under export/ ("" for the root)
+	Action       string    // skip, incremental or overwrite
+	JSON         bool      // Print progress as JSON lines
+	Output       io.Writer // Where progress is written (stdout)
+	Log          io.Writer // Where warnings are written (stderr in JSON mode)
+	Workers      int       // Number of channels downloaded in parallel
+}
+
+// progressLine is the JSON form of a progress event
+type progressLine struct {
+	ChannelID string `json:"channel_id"`
+	Channel   string `json:"channel"`
+	Status    string `json:"status,omitempty"`
+	Current   int    `json:"current"`
+	Total     int    `json:"total"`
+	Done      bool   `json:"done"`
+	Skipped   bool   `json:"skipped,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// selectChannels resolves the --channels and --channel-regex flags to download targets
+func selectChannels(channels []slackgo.Channel, opts headlessOptions) ([]downloader.Target, error) {
+	var targets []downloader.Target
+	seen := make(map[string]bool)
+	add := func(ch slackgo.Channel) {
+		if !seen[ch.ID] {
+			seen[ch.ID] = true
+			targets = append(targets, downloader.Target{ID: ch.ID, Name: ch.Name})
+		}
+	}
+
+	if opts.Channels != "" {
+		for _, want := range strings.Split(opts.Channels, ",") {
+			want = strings.TrimPrefix(strings.TrimSpace(want), "#")
+			if want == "" {
+				continue
+			}
+			found := false
+			for _, ch := range channels {
+				if ch.ID == want || ch.Name == want {
+					add(ch)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("channel not found: %s", want)
+			}
+		}
+	}
+
+	if opts.ChannelRegex != "" {
+		re, err := regexp.Compile(opts.ChannelRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --channel-regex: %w", err)
+		}
+		for _, ch := range channels {
+			if ch.Name != "" && re.MatchString(ch.Name) {
+				add(ch)
+			}
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no channels matched")
+	}
+	return targets, nil
+}
+
+// runHeadless downloads the selected channels without the TUI.
+// It returns the number of channels that failed.
+func runHeadless(ctx context.Context, d *downloader.Downloader, channels []slackgo.Channel, opts headlessOptions) (int, error) {
+	switch opts.Action {
+	case downloader.ActionSkip, downloader.ActionIncremental, downloader.ActionOverwrite:
+	default:
+		return 0, fmt.Errorf("invalid --action %q (want skip, incremental or overwrite)", opts.Action)
+	}
+
+	targets, err := selectChannels(channels, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	// Scan existing files the same way the confirm screen does
+	scanResult, err := manager.ScanExportDir(d.ExportRoot)
+	if err != nil {
+		d.ExistingFiles = make(map[string]manager.ChannelMeta)
+	} else {
+		d.ExistingFiles = scanResult.Channels
+		for _, w := range scanResult.Warnings {
+			fmt.Fprintf(opts.Log, "Warning: %s\n", w)
+		}
+	}
+
+	d.Action = opts.Action
+	d.TargetFolder = d.ExportRoot
+	if opts.Folder != "" && opts.Folder != "." {
+		d.TargetFolder = filepath.Join(d.ExportRoot, opts.Folder)
+	}
+
+	encoder := json.NewEncoder(opts.Output)
+	finished, skipped := 0, 0
+
+	failed := d.DownloadAll(ctx, targets, opts.Workers, func(e downloader.Event) {
+		if e.Done {
+			finished++
+		}
+		if e.Skipped {
+			skipped++
+		}
+
+		if opts.JSON {
+			line := progressLine{
+				ChannelID: e.ChannelID,
+				Channel:   e.ChannelName,
+				Status:    e.Status,
+				Current:   e.Current,
+				Total:     e.Total,
+				Done:      e.Done,
+				Skipped:   e.Skipped,
+			}
+			if e.Err != nil {
+				line.Error = e.Err.Error()
+			}
+			encoder.Encode(line)
+			return
+		}
+
+		prefix := fmt.Sprintf("[%d/%d] %s:", finished, len(targets), e.ChannelName)
+		if e.Err != nil {
+			fmt.Fprintf(opts.Output, "%s ERROR %v\n", prefix, e.Err)
+		} else if e.Current > 0 {
+			fmt.Fprintf(opts.Output, "%s %s (%d)\n", prefix, e.Status, e.Current)
+		} else {
+			fmt.Fprintf(opts.Output, "%s %s\n", prefix, e.Status)
+		}
+	})
+
+	if !opts.JSON {
+		fmt.Fprintf(opts.Output, "Finished: %d downloaded, %d skipped, %d failed\n", len(targets)-skipped-failed, skipped, failed)
+	}
+	return failed, nil
+}
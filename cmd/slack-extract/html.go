@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/chanseok/slackExtract/internal/config"
+	"github.com/chanseok/slackExtract/internal/export"
+	"github.com/chanseok/slackExtract/internal/meta"
+	"github.com/chanseok/slackExtract/internal/slack"
+	"github.com/chanseok/slackExtract/internal/store"
+)
+
+// runHTML writes the stored channels as a static HTML site without calling
+// the Slack API. It returns the process exit code.
+func runHTML(args []string) int {
+	fs := flag.NewFlagSet("html", flag.ExitOnError)
+	folder := fs.String("folder", "", "Only include channels in this subfolder of export/ (default: all)")
+	out := fs.String("out", "site", "Folder to write the site to")
+	fs.Usage = func() {
+		fmt.Println("Usage: slack-extract html [--folder NAME] [--out DIR]")
+		fmt.Println("")
+		fmt.Println("Writes export/.raw as a static HTML site with search; open index.html in a browser.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	const exportRoot = "export"
+	cfg := config.LoadLocal()
+
+	metaManager, err := meta.NewManager(exportRoot)
+	if err != nil {
+		fmt.Printf("Error loading metadata index: %v\n", err)
+		return 1
+	}
+
+	users, err := slack.LoadUserDirectory()
+	if err != nil {
+		fmt.Printf("Warning: Could not load user cache: %v\n", err)
+		users = slack.NewUserDirectory()
+	}
+
+	cached := loadChannelDetails()
+
+	site, err := export.NewSite(*out, users, export.NewOptions(cfg))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	raw := store.New(exportRoot)
+	written := 0
+
+	for _, ch := range metaManager.Channels() {
+		if ch.Path == "" || !raw.Exists(ch.ID) {
+			continue
+		}
+		relFolder := filepath.Dir(ch.Path)
+		if *folder != "" && filepath.Clean(*folder) != relFolder {
+			continue
+		}
+
+		months, err := raw.Months(ch.ID)
+		if err != nil {
+			fmt.Printf("  ❌ %s: %v\n", ch.Name, err)
+			site.Abort()
+			return 1
+		}
+		info, ok := cached[ch.ID]
+		if !ok {
+			info = slack.CachedChannel{ID: ch.ID, Name: ch.Name, IsChannel: true}
+		}
+		// The pages are named after the exported file, which DMs keep even when renamed
+		info.Name = ch.Name
+
+		channelID := ch.ID
+		err = site.AddChannel(ctx, export.SiteChannel{
+			Info:         info,
+			TargetFolder: filepath.Join(exportRoot, relFolder),
+			Months:       months,
+			Load: func(month string) ([]slack.Message, error) {
+				return raw.LoadMonth(channelID, month)
+			},
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				fmt.Println("Cancelled.")
+			} else {
+				fmt.Printf("  ❌ %s: %v\n", ch.Name, err)
+			}
+			site.Abort()
+			return 1
+		}
+		fmt.Printf("  ✅ %s (%d months)\n", ch.Name, len(months))
+		written++
+	}
+
+	if err := site.Close(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote %d channels to %s\n", written, filepath.Join(*out, "index.html"))
+	return 0
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/chanseok/slackExtract/internal/config"
+	"github.com/chanseok/slackExtract/internal/downloader"
+	"github.com/chanseok/slackExtract/internal/importer"
+	"github.com/chanseok/slackExtract/internal/meta"
+	"github.com/chanseok/slackExtract/internal/slack"
+)
+
+// runImport converts an official Slack export ZIP into the export directory
+// (raw store, Markdown and metadata index). It needs no token or cookie.
+// It returns the process exit code.
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	folder := fs.String("folder", "", "Subfolder under export/ to write to (default: the root)")
+	channels := fs.String("channels", "", "Comma-separated channel names or IDs to import (default: all)")
+	formatFlag := fs.String("format", "", "Comma-separated export formats: "+strings.Join(config.ExportFormatNames, ", ")+" (default: EXPORT_FORMATS or markdown)")
+	fs.Usage = func() {
+		fmt.Println("Usage: slack-extract import [--folder NAME] [--channels a,b] [--format LIST] <slack-export.zip>")
+		fmt.Println("")
+		fmt.Println("Imports a Slack workspace export archive without calling the Slack API.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	cfg := config.LoadLocal()
+	if *formatFlag != "" {
+		formats, err := config.ParseFormats(*formatFlag)
+		if err != nil {
+			fmt.Printf("Error: --format: %v\n", err)
+			return 1
+		}
+		cfg.ExportFormats = formats
+	}
+
+	archive, err := importer.OpenZip(fs.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	defer archive.Close()
+	fmt.Printf("Found %d channels and %d users in %s\n", len(archive.Channels), len(archive.Users), fs.Arg(0))
+
+	// Merge the archive's users into the local cache so render and
+	// later downloads resolve the same names. Known users are kept.
+	users, err := slack.LoadUserDirectory()
+	if err != nil {
+		users = slack.NewUserDirectory()
+	}
+	for _, u := range archive.Users {
+		if _, exists := users.Lookup(u.ID); !exists {
+			users.Add(u)
+		}
+	}
+	if err := users.Save(); err != nil {
+		fmt.Printf("Warning: Could not save user cache: %v\n", err)
+	}
+
+	// DMs and group DMs are named after their participants, as in a sync,
+	// and the channel cache learns every imported conversation so exports
+	// know its type
+	nameImported(archive.Channels, users)
+	if err := mergeChannelCache(archive.Channels); err != nil {
+		fmt.Printf("Warning: Could not save channel cache: %v\n", err)
+	}
+
+	metaManager, err := meta.NewManager("export")
+	if err != nil {
+		fmt.Printf("Warning: Could not initialize metadata manager: %v\n", err)
+	}
+
+	// Attachments in an export need Slack credentials, so they are only linked
+	d := downloader.New(nil, nil, users, cfg, metaManager)
+	d.Options.DownloadAttachments = false
+	if *folder != "" && *folder != "." {
+		d.TargetFolder = filepath.Join(d.ExportRoot, *folder)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	wanted := make(map[string]bool)
+	for _, name := range strings.Split(*channels, ",") {
+		if name = strings.TrimPrefix(strings.TrimSpace(name), "#"); name != "" {
+			wanted[name] = true
+		}
+	}
+
+	imported, failed := 0, 0
+	for _, ch := range archive.Channels {
+		if ctx.Err() != nil {
+			fmt.Println("Cancelled.")
+			failed++
+			break
+		}
+		if len(wanted) > 0 && !wanted[ch.ID] && !wanted[ch.Name] {
+			continue
+		}
+		name := ch.Name
+		if name == "" {
+			name = ch.ID
+		}
+
+		msgs, err := archive.Messages(ch.ID)
+		if err == nil {
+			err = d.SaveChannel(ctx, downloader.Target{ID: ch.ID, Name: name}, msgs)
+		}
+		if err != nil {
+			fmt.Printf("  ❌ %s: %v\n", name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("  ✅ %s (%d messages)\n", name, len(msgs))
+		imported++
+	}
+
+	fmt.Printf("Imported %d channels, %d failed\n", imported, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// nameImported names the archive's DMs and group DMs with
+// slack.ConversationName. A workspace export has no "self", so a DM's other
+// participant is taken from the channel cache when it was synced before,
+// and is otherwise the last listed member. That guess can give two DMs the
+// same name, so a DM or group DM whose name is taken within the archive
+// gets its channel ID appended.
+func nameImported(channels []slack.CachedChannel, users *slack.UserDirectory) {
+	known := make(map[string]slack.CachedChannel)
+	if cached, err := slack.LoadCachedChannels(); err == nil {
+		for _, ch := range cached {
+			known[ch.ID] = ch
+		}
+	}
+	for i := range channels {
+		ch := &channels[i]
+		if !ch.IsIM && !ch.IsMpIM {
+			continue
+		}
+		if ch.IsIM && ch.User == "" {
+			if old, ok := known[ch.ID]; ok && old.User != "" {
+				ch.User = old.User
+			} else if len(ch.Members) > 0 {
+				ch.User = ch.Members[len(ch.Members)-1]
+			}
+		}
+		ch.Name = slack.ConversationName(ch.ID, ch.Name, ch.User, ch.IsIM, users)
+	}
+
+	count := make(map[string]int, len(channels))
+	for _, ch := range channels {
+		count[ch.Name]++
+	}
+	for i := range channels {
+		ch := &channels[i]
+		if (ch.IsIM || ch.IsMpIM) && count[ch.Name] > 1 && ch.Name != ch.ID {
+			ch.Name += "-" + ch.ID
+		}
+	}
+}
+
+// mergeChannelCache adds the imported conversations to the channel cache
+// (channels.json). Channels already cached keep their entry; added ones are
+// marked Imported so a later channel refresh keeps them.
+func mergeChannelCache(channels []slack.CachedChannel) error {
+	cached, err := slack.LoadCachedChannels()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	seen := make(map[string]bool, len(cached))
+	for _, ch := range cached {
+		seen[ch.ID] = true
+	}
+	added := false
+	for _, ch := range channels {
+		if !seen[ch.ID] {
+			ch.Imported = true
+			cached = append(cached, ch)
+			added = true
+		}
+	}
+	if !added {
+		return nil
+	}
+	return slack.SaveCachedChannels(cached)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+func TestNameImportedCollisions(t *testing.T) {
+	t.Chdir(t.TempDir())
+	users := slack.NewUserDirectory()
+	users.Add(
+		slackgo.User{ID: "U1", Name: "alice"},
+		slackgo.User{ID: "U2", Name: "bob"},
+		slackgo.User{ID: "U3", Name: "carol"},
+	)
+
+	channels := []slack.CachedChannel{
+		{ID: "C1", Name: "general", IsChannel: true},
+		{ID: "D1", IsIM: true, Members: []string{"U1", "U3"}},
+		{ID: "D2", IsIM: true, Members: []string{"U2", "U3"}},
+		{ID: "D3", IsIM: true, Members: []string{"U1", "U2"}},
+	}
+	nameImported(channels, users)
+
+	want := map[string]string{
+		"C1": "general",
+		"D1": "dm-carol-D1",
+		"D2": "dm-carol-D2",
+		"D3": "dm-bob",
+	}
+	for _, ch := range channels {
+		if ch.Name != want[ch.ID] {
+			t.Errorf("%s: name = %q, want %q", ch.ID, ch.Name, want[ch.ID])
+		}
+	}
+}
+
+func TestNameImportedKnownUser(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := slack.SaveCachedChannels([]slack.CachedChannel{{ID: "D1", IsIM: true, User: "U1"}}); err != nil {
+		t.Fatal(err)
+	}
+	users := slack.NewUserDirectory()
+	users.Add(slackgo.User{ID: "U1", Name: "alice"}, slackgo.User{ID: "U3", Name: "carol"})
+
+	channels := []slack.CachedChannel{
+		{ID: "D1", IsIM: true, Members: []string{"U1", "U3"}},
+		{ID: "D2", IsIM: true, Members: []string{"U2", "U3"}},
+	}
+	nameImported(channels, users)
+
+	if channels[0].User != "U1" || channels[0].Name != "dm-alice" {
+		t.Errorf("D1 = %q (%s), want dm-alice (U1)", channels[0].Name, channels[0].User)
+	}
+	if channels[1].Name != "dm-carol" {
+		t.Errorf("D2 = %q, want dm-carol", channels[1].Name)
+	}
+}
+
+func TestMergeChannelCache(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := slack.SaveCachedChannels([]slack.CachedChannel{{ID: "C1", Name: "general"}}); err != nil {
+		t.Fatal(err)
+	}
+	err := mergeChannelCache([]slack.CachedChannel{
+		{ID: "C1", Name: "general-old"},
+		{ID: "D1", Name: "dm-alice", IsIM: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := slack.LoadCachedChannels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cached) != 2 {
+		t.Fatalf("cached %d channels, want 2", len(cached))
+	}
+	if cached[0].Name != "general" || cached[0].Imported {
+		t.Errorf("C1 = %+v, want the synced entry", cached[0])
+	}
+	if cached[1].ID != "D1" || !cached[1].Imported {
+		t.Errorf("D1 = %+v, want an imported entry", cached[1])
+	}
+}
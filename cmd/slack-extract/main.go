@@ -1,67 +1,160 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/chanseok/slackExtract/internal/config"
+	"github.com/chanseok/slackExtract/internal/downloader"
 	"github.com/chanseok/slackExtract/internal/meta"
 	"github.com/chanseok/slackExtract/internal/slack"
 	"github.com/chanseok/slackExtract/internal/tui"
 )
 
 func main() {
+	// Subcommands that work on local data only
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "render":
+			os.Exit(runRender(os.Args[2:]))
+		case "import":
+			os.Exit(runImport(os.Args[2:]))
+		case "zip":
+			os.Exit(runZip(os.Args[2:]))
+		case "html":
+			os.Exit(runHTML(os.Args[2:]))
+		case "obsidian":
+			os.Exit(runObsidian(os.Args[2:]))
+		}
+	}
+
 	// Parse flags
 	refresh := flag.Bool("refresh", false, "Force refresh of user and channel cache")
+
+	// Headless mode flags (any of --channels / --channel-regex skips the TUI)
+	var opts headlessOptions
+	flag.StringVar(&opts.Channels, "channels", "", "Comma-separated channel names or IDs to download without the TUI")
+	flag.StringVar(&opts.ChannelRegex, "channel-regex", "", "Download channels whose name matches this regular expression without the TUI")
+	flag.StringVar(&opts.Folder, "folder", "", "Subfolder under export/ to write to (headless mode)")
+	flag.StringVar(&opts.Action, "action", downloader.ActionSkip, "What to do with existing files: skip, incremental or overwrite (headless mode)")
+	flag.BoolVar(&opts.JSON, "json", false, "Print progress as JSON lines (headless mode)")
+	flag.IntVar(&opts.Workers, "workers", 0, "Number of channels to download in parallel (default: DOWNLOAD_WORKERS or 3)")
+	since := flag.String("since", "", "Only download messages from this date (2025-07-01, 2025-07, 2025-Q3) or age (30d, 12w, 6m)")
+	until := flag.String("until", "", "Only download messages up to this date (inclusive) or age")
+	formatFlag := flag.String("format", "", "Comma-separated export formats: "+strings.Join(config.ExportFormatNames, ", ")+" (default: EXPORT_FORMATS or markdown)")
 	flag.Parse()
+	headless := opts.Channels != "" || opts.ChannelRegex != ""
+
+	// In JSON mode stdout carries only the JSON lines. Messages, including
+	// those of the packages used below, are written to out, then stderr.
+	out := io.Writer(os.Stdout)
+	if headless && opts.JSON {
+		out = os.Stderr
+	}
+	opts.Output, opts.Log = os.Stdout, out
+
+	dateRange, err := downloader.ParseDateRange(*since, *until, time.Now())
+	if err != nil {
+		fmt.Fprintf(out, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	// 1. Load Config
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
-		fmt.Println("Please check your .env file.")
+		fmt.Fprintf(out, "Error loading config: %v\n", err)
+		fmt.Fprintln(out, "Please check your .env file.")
 		os.Exit(1)
 	}
+	if *formatFlag != "" {
+		if cfg.ExportFormats, err = config.ParseFormats(*formatFlag); err != nil {
+			fmt.Fprintf(out, "Error: --format: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-	fmt.Println("Slack Extract - Initializing...")
+	fmt.Fprintln(out, "Slack Extract - Initializing...")
 
 	// Initialize Metadata Manager
 	metaManager, err := meta.NewManager("export")
 	if err != nil {
-		fmt.Printf("Warning: Could not initialize metadata manager: %v\n", err)
+		fmt.Fprintf(out, "Warning: Could not initialize metadata manager: %v\n", err)
 		// Continue without metadata manager if it fails, or handle it appropriately
 	}
 
 	// 2. Initialize Slack Client
-	client, httpClient, err := slack.NewClient(cfg)
+	client, httpClient, err := slack.NewClient(cfg, out)
 	if err != nil {
-		fmt.Printf("Error initializing Slack client: %v\n", err)
+		fmt.Fprintf(out, "Error initializing Slack client: %v\n", err)
 		os.Exit(1)
 	}
 
-	// 3. Fetch Channels (with caching)
-	channels, err := slack.FetchChannels(client, *refresh)
+	// 3. Fetch Channels (with caching). The TUI has not started yet, so
+	// rate limit waits are printed.
+	retryCfg := slack.DefaultRetryConfig()
+	retryCfg.Limiter = slack.NewRateLimiter()
+	printWait := func(_, _ int, status string) {
+		fmt.Fprintln(out, status)
+	}
+	channels, err := slack.FetchChannelsWithRetry(context.Background(), client, retryCfg, *refresh, out, printWait)
 	if err != nil {
-		fmt.Printf("Error fetching channels: %v\n", err)
+		fmt.Fprintf(out, "Error fetching channels: %v\n", err)
 		os.Exit(1)
 	}
 
 	// 4. Fetch Users (with caching)
-	userMap, err := slack.FetchUsers(client, *refresh)
+	users, err := slack.FetchUsersWithRetry(context.Background(), client, retryCfg, *refresh, out, printWait)
 	if err != nil {
-		fmt.Printf("Warning: Could not fetch users: %v\n", err)
-		userMap = make(map[string]string)
+		fmt.Fprintf(out, "Warning: Could not fetch users: %v\n", err)
+		users = slack.NewUserDirectory()
+	}
+
+	// DMs are named after their participants, keeping names from earlier syncs
+	knownNames := make(map[string]string)
+	if metaManager != nil {
+		for _, ch := range metaManager.Channels() {
+			knownNames[ch.ID] = ch.Name
+		}
+	}
+	slack.NameConversations(channels, users, knownNames)
+
+	// 5. Headless mode
+	if headless {
+		if opts.Workers == 0 {
+			opts.Workers = cfg.DownloadWorkers
+		}
+		d := downloader.New(client, httpClient, users, cfg, metaManager)
+		d.Range = dateRange
+
+		// Ctrl+C stops the download; channels being saved are finished first
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		failed, err := runHeadless(ctx, d, channels, opts)
+		if err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return
 	}
 
-	// 5. Run TUI
-	initialModel := tui.NewModel(channels, client, httpClient, userMap, cfg, metaManager)
+	// 6. Run TUI
+	initialModel := tui.NewModel(channels, client, httpClient, users, cfg, metaManager)
+	initialModel.DateRange = dateRange
 	p := tea.NewProgram(initialModel, tea.WithAltScreen())
 	_, err = p.Run()
 	if err != nil {
-		fmt.Printf("Alas, there's been an error: %v", err)
+		fmt.Fprintf(out, "Alas, there's been an error: %v", err)
 		os.Exit(1)
 	}
 }
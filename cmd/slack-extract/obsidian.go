@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/chanseok/slackExtract/internal/config"
+	"github.com/chanseok/slackExtract/internal/export"
+	"github.com/chanseok/slackExtract/internal/meta"
+	"github.com/chanseok/slackExtract/internal/slack"
+	"github.com/chanseok/slackExtract/internal/store"
+)
+
+// runObsidian writes the stored channels as an Obsidian vault without
+// calling the Slack API. It returns the process exit code.
+func runObsidian(args []string) int {
+	fs := flag.NewFlagSet("obsidian", flag.ExitOnError)
+	folder := fs.String("folder", "", "Only include channels in this subfolder of export/ (default: all)")
+	out := fs.String("out", "vault", "Folder to write the vault to")
+	split := fs.String("split", string(export.VaultByChannel), "Write one note per channel or per month: channel, month")
+	fs.Usage = func() {
+		fmt.Println("Usage: slack-extract obsidian [--folder NAME] [--out DIR] [--split channel|month]")
+		fmt.Println("")
+		fmt.Println("Writes export/.raw as an Obsidian vault with linked channel and person notes.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	vaultSplit := export.VaultSplit(*split)
+	if vaultSplit != export.VaultByChannel && vaultSplit != export.VaultByMonth {
+		fmt.Printf("Error: unknown --split %q (use channel or month)\n", *split)
+		return 2
+	}
+
+	const exportRoot = "export"
+	cfg := config.LoadLocal()
+
+	metaManager, err := meta.NewManager(exportRoot)
+	if err != nil {
+		fmt.Printf("Error loading metadata index: %v\n", err)
+		return 1
+	}
+
+	users, err := slack.LoadUserDirectory()
+	if err != nil {
+		fmt.Printf("Warning: Could not load user cache, writing no person notes: %v\n", err)
+		users = slack.NewUserDirectory()
+	}
+
+	cached := loadChannelDetails()
+
+	// Every channel is known before the first note is written, so links
+	// between channels resolve in either direction
+	raw := store.New(exportRoot)
+	var channels []export.VaultChannel
+	for _, ch := range metaManager.Channels() {
+		if ch.Path == "" || !raw.Exists(ch.ID) {
+			continue
+		}
+		relFolder := filepath.Dir(ch.Path)
+		if *folder != "" && filepath.Clean(*folder) != relFolder {
+			continue
+		}
+
+		months, err := raw.Months(ch.ID)
+		if err != nil {
+			fmt.Printf("  ❌ %s: %v\n", ch.Name, err)
+			return 1
+		}
+		info, ok := cached[ch.ID]
+		if !ok {
+			info = slack.CachedChannel{ID: ch.ID, Name: ch.Name, IsChannel: true}
+		}
+		// Notes are named after the exported file, which DMs keep even when renamed
+		info.Name = ch.Name
+
+		channelID := ch.ID
+		vc := export.VaultChannel{
+			Info:         info,
+			TargetFolder: filepath.Join(exportRoot, relFolder),
+			Months:       months,
+			Load: func(month string) ([]slack.Message, error) {
+				return raw.LoadMonth(channelID, month)
+			},
+		}
+		if relFolder != "." {
+			vc.Folder = filepath.ToSlash(relFolder)
+		}
+		channels = append(channels, vc)
+	}
+
+	vault, err := export.NewVault(*out, users, export.NewOptions(cfg), vaultSplit, channels)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	for _, ch := range channels {
+		if err := vault.AddChannel(ctx, ch); err != nil {
+			if ctx.Err() != nil {
+				fmt.Println("Cancelled.")
+			} else {
+				fmt.Printf("  ❌ %s: %v\n", ch.Info.Name, err)
+			}
+			return 1
+		}
+		fmt.Printf("  ✅ %s (%d months)\n", ch.Info.Name, len(ch.Months))
+	}
+
+	if err := vault.Close(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote %d channels to %s; open the folder as a vault in Obsidian\n", len(channels), *out)
+	return 0
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/chanseok/slackExtract/internal/config"
+	"github.com/chanseok/slackExtract/internal/export"
+	"github.com/chanseok/slackExtract/internal/meta"
+	"github.com/chanseok/slackExtract/internal/slack"
+	"github.com/chanseok/slackExtract/internal/store"
+)
+
+// runRender regenerates channel files from the raw store without calling
+// the Slack API. It returns the process exit code.
+func runRender(args []string) int {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	folder := fs.String("folder", "", "Only re-render channels in this subfolder of export/ (default: all)")
+	formatFlag := fs.String("format", "", "Comma-separated export formats: "+strings.Join(config.ExportFormatNames, ", ")+" (default: EXPORT_FORMATS or markdown)")
+	fs.Usage = func() {
+		fmt.Println("Usage: slack-extract render [--folder NAME] [--format LIST]")
+		fmt.Println("")
+		fmt.Println("Rebuilds exported channel files from export/.raw without calling Slack.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	const exportRoot = "export"
+	cfg := config.LoadLocal()
+	if *formatFlag != "" {
+		formats, err := config.ParseFormats(*formatFlag)
+		if err != nil {
+			fmt.Printf("Error: --format: %v\n", err)
+			return 2
+		}
+		cfg.ExportFormats = formats
+	}
+	exporters, err := export.Exporters(cfg.ExportFormats)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 2
+	}
+
+	metaManager, err := meta.NewManager(exportRoot)
+	if err != nil {
+		fmt.Printf("Error loading metadata index: %v\n", err)
+		return 1
+	}
+
+	users, err := slack.LoadUserDirectory()
+	if err != nil {
+		fmt.Printf("Warning: Could not load user cache: %v\n", err)
+	}
+
+	opts := export.NewOptions(cfg)
+	userMap := export.UserNames(users, opts)
+	raw := store.New(exportRoot)
+	rendered, failed := 0, 0
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	for _, ch := range metaManager.Channels() {
+		if ctx.Err() != nil {
+			fmt.Println("Cancelled.")
+			failed++
+			break
+		}
+		if ch.Path == "" || !raw.Exists(ch.ID) {
+			continue
+		}
+		relFolder := filepath.Dir(ch.Path)
+		if *folder != "" && filepath.Clean(*folder) != relFolder {
+			continue
+		}
+
+		// Messages are read from the store one month at a time, once per pass
+		count := 0
+		msgs := func(fn func(slack.Message) error) error {
+			count = 0
+			return raw.Each(ch.ID, func(msg slack.Message) error {
+				count++
+				return fn(msg)
+			})
+		}
+
+		// No HTTP client: attachments are linked locally if they were downloaded before
+		target := export.Channel{
+			ID:           ch.ID,
+			Name:         ch.Name,
+			UserMap:      userMap,
+			Options:      opts,
+			TargetFolder: filepath.Join(exportRoot, relFolder),
+		}
+		if relFolder != "." {
+			target.Folder = filepath.ToSlash(relFolder)
+		}
+		var written []string
+		var saveErr error
+		for _, e := range exporters {
+			filePath, err := export.Save(ctx, e, target, msgs)
+			if err != nil {
+				saveErr = fmt.Errorf("%s: %w", e.Format(), err)
+				break
+			}
+			relPath, _ := filepath.Rel(exportRoot, filePath)
+			metaManager.RecordChannelFile(ch.ID, e.Format(), relPath)
+			written = append(written, relPath)
+		}
+		if saveErr != nil {
+			fmt.Printf("  ❌ %s: %v\n", ch.Name, saveErr)
+			failed++
+			continue
+		}
+		fmt.Printf("  ✅ %s (%d messages)\n", strings.Join(written, ", "), count)
+		rendered++
+	}
+
+	if err := metaManager.SaveIndex(); err != nil {
+		fmt.Printf("Error saving metadata index: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Rendered %d channels, %d failed\n", rendered, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// loadChannelDetails returns the channel list cache by channel ID. It holds
+// the details (type, topic, purpose) the index lacks; without it only names
+// are written.
+func loadChannelDetails() map[string]slack.CachedChannel {
+	cached := make(map[string]slack.CachedChannel)
+	channels, err := slack.LoadCachedChannels()
+	if err != nil {
+		fmt.Printf("Warning: Could not load channel cache, writing names only: %v\n", err)
+	}
+	for _, ch := range channels {
+		cached[ch.ID] = ch
+	}
+	return cached
+}
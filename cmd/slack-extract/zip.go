@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/chanseok/slackExtract/internal/export"
+	"github.com/chanseok/slackExtract/internal/meta"
+	"github.com/chanseok/slackExtract/internal/slack"
+	"github.com/chanseok/slackExtract/internal/store"
+)
+
+// runZip writes the stored channels as an official Slack export archive
+// without calling the Slack API. It returns the process exit code.
+func runZip(args []string) int {
+	fs := flag.NewFlagSet("zip", flag.ExitOnError)
+	folder := fs.String("folder", "", "Only include channels in this subfolder of export/ (default: all)")
+	out := fs.String("out", "slack-export.zip", "Path of the archive to write")
+	fs.Usage = func() {
+		fmt.Println("Usage: slack-extract zip [--folder NAME] [--out FILE]")
+		fmt.Println("")
+		fmt.Println("Writes export/.raw as a Slack workspace export ZIP (channels.json, users.json, per-day JSON).")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	const exportRoot = "export"
+
+	metaManager, err := meta.NewManager(exportRoot)
+	if err != nil {
+		fmt.Printf("Error loading metadata index: %v\n", err)
+		return 1
+	}
+
+	users, err := slack.LoadUserDirectory()
+	if err != nil {
+		fmt.Printf("Warning: Could not load user cache: %v\n", err)
+		users = slack.NewUserDirectory()
+	}
+
+	cached := loadChannelDetails()
+
+	archive, err := export.NewSlackZip(*out)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+
+	raw := store.New(exportRoot)
+	written := 0
+
+	for _, ch := range metaManager.Channels() {
+		if ch.Path == "" || !raw.Exists(ch.ID) {
+			continue
+		}
+		if *folder != "" && filepath.Clean(*folder) != filepath.Dir(ch.Path) {
+			continue
+		}
+
+		// Messages are read a month at a time, so large channels fit in memory
+		months, err := raw.Months(ch.ID)
+		if err != nil {
+			fmt.Printf("  ❌ %s: %v\n", ch.Name, err)
+			archive.Abort()
+			return 1
+		}
+
+		info, ok := cached[ch.ID]
+		if !ok {
+			info = slack.CachedChannel{ID: ch.ID, Name: ch.Name, IsChannel: true}
+		}
+		channelID := ch.ID
+		count, err := archive.AddChannel(info, months, func(month string) ([]slack.Message, error) {
+			return raw.LoadMonth(channelID, month)
+		})
+		if err != nil {
+			fmt.Printf("  ❌ %s: %v\n", ch.Name, err)
+			archive.Abort()
+			return 1
+		}
+		fmt.Printf("  ✅ %s (%d messages)\n", ch.Name, count)
+		written++
+	}
+
+	if err := archive.Close(users); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote %d channels to %s\n", written, *out)
+	return 0
+}
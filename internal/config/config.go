@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -10,7 +12,15 @@ import (
 type Config struct {
 	UserToken           string
 	DSCookie            string
+	SlackAPIURL         string // Overrides the Slack Web API URL, e.g. for the fake-slack server
 	DownloadAttachments bool
+	ShowEditHistory     bool     // Render earlier versions of edited messages
+	ShowReactionUsers   bool     // Render who reacted next to each emoji
+	UserNameStyle       string   // Which user name is shown: real, display or handle
+	MarkDeactivated     bool     // Mark messages of deactivated users
+	DownloadWorkers     int      // Number of channels downloaded in parallel
+	ThreadLookbackDays  int      // How far back incremental sync looks for threads with new replies
+	ExportFormats       []string // Formats channel files are written in, e.g. markdown and jsonl
 	LLMProvider         string
 	LLMAPIKey           string
 	LLMModel            string
@@ -18,6 +28,61 @@ type Config struct {
 }
 
 func Load() (*Config, error) {
+	cfg := LoadLocal()
+	if cfg.UserToken == "" || cfg.DSCookie == "" {
+		return nil, fmt.Errorf("SLACK_USER_TOKEN (xoxc-...) and SLACK_DS_COOKIE (xoxd-...) are required")
+	}
+	switch cfg.UserNameStyle {
+	case "real", "display", "handle":
+	default:
+		return nil, fmt.Errorf("USER_NAME_STYLE must be real, display or handle, got %q", cfg.UserNameStyle)
+	}
+	if _, err := ParseFormats(os.Getenv("EXPORT_FORMATS")); err != nil {
+		return nil, fmt.Errorf("EXPORT_FORMATS: %w", err)
+	}
+	return cfg, nil
+}
+
+// ExportFormatNames lists the formats channel files can be written in
+var ExportFormatNames = []string{"markdown", "json", "jsonl", "csv", "text"}
+
+// ParseFormats splits a comma-separated list of export formats such as
+// "markdown,jsonl", accepting "md" and "txt" as aliases. An empty list
+// means Markdown only.
+func ParseFormats(value string) ([]string, error) {
+	var formats []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "":
+			continue
+		case "md":
+			name = "markdown"
+		case "txt":
+			name = "text"
+		}
+		valid := false
+		for _, known := range ExportFormatNames {
+			valid = valid || name == known
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown format %q (use %s)", name, strings.Join(ExportFormatNames, ", "))
+		}
+		if !seen[name] {
+			formats = append(formats, name)
+			seen[name] = true
+		}
+	}
+	if len(formats) == 0 {
+		formats = []string{"markdown"}
+	}
+	return formats, nil
+}
+
+// LoadLocal loads the configuration without requiring Slack credentials,
+// for commands that work only on local data
+func LoadLocal() *Config {
 	// Load .env file
 	err := godotenv.Load()
 	if err != nil {
@@ -28,7 +93,32 @@ func Load() (*Config, error) {
 
 	token := os.Getenv("SLACK_USER_TOKEN")
 	dCookie := os.Getenv("SLACK_DS_COOKIE")
+	slackAPIURL := os.Getenv("SLACK_API_URL")
 	downloadAttachments := os.Getenv("DOWNLOAD_ATTACHMENTS") == "true"
+	showEditHistory := os.Getenv("SHOW_EDIT_HISTORY") == "true"
+	showReactionUsers := os.Getenv("SHOW_REACTION_USERS") == "true"
+	markDeactivated := os.Getenv("MARK_DEACTIVATED_USERS") != "false"
+
+	userNameStyle := os.Getenv("USER_NAME_STYLE")
+	if userNameStyle == "" {
+		userNameStyle = "real"
+	}
+
+	downloadWorkers := 3
+	if v, err := strconv.Atoi(os.Getenv("DOWNLOAD_WORKERS")); err == nil && v > 0 {
+		downloadWorkers = v
+	}
+
+	threadLookbackDays := 14
+	if v, err := strconv.Atoi(os.Getenv("THREAD_LOOKBACK_DAYS")); err == nil && v >= 0 {
+		threadLookbackDays = v
+	}
+
+	// Load reports an invalid list; local commands fall back to Markdown
+	exportFormats, err := ParseFormats(os.Getenv("EXPORT_FORMATS"))
+	if err != nil {
+		exportFormats = []string{"markdown"}
+	}
 	
 	// LLM Configuration (optional)
 	llmProvider := os.Getenv("LLM_PROVIDER")
@@ -47,17 +137,21 @@ func Load() (*Config, error) {
 	llmModel := os.Getenv("LLM_MODEL")
 	llmBaseURL := os.Getenv("LLM_BASE_URL")
 
-	if token == "" || dCookie == "" {
-		return nil, fmt.Errorf("SLACK_USER_TOKEN (xoxc-...) and SLACK_DS_COOKIE (xoxd-...) are required")
-	}
-
 	return &Config{
 		UserToken:           token,
 		DSCookie:            dCookie,
+		SlackAPIURL:         slackAPIURL,
 		DownloadAttachments: downloadAttachments,
+		ShowEditHistory:     showEditHistory,
+		ShowReactionUsers:   showReactionUsers,
+		UserNameStyle:       userNameStyle,
+		MarkDeactivated:     markDeactivated,
+		DownloadWorkers:     downloadWorkers,
+		ThreadLookbackDays:  threadLookbackDays,
+		ExportFormats:       exportFormats,
 		LLMProvider:         llmProvider,
 		LLMAPIKey:           llmAPIKey,
 		LLMModel:            llmModel,
 		LLMBaseURL:          llmBaseURL,
-	}, nil
+	}
 }
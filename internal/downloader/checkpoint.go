@@ -0,0 +1,88 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/meta"
+	"github.com/chanseok/slackExtract/internal/slack"
+)
+
+// fetchHistory fetches the history of a channel between oldest and latest.
+// Pages are written to the checkpoint under .meta as they arrive instead of
+// being kept in memory, so a fetch that is interrupted resumes from its last
+// page on the next run, and a finished fetch whose save was interrupted is
+// not fetched again. The returned checkpoint holds the fetched messages and
+// the bounds they were fetched with: a relative date range (--since 30d)
+// resumes with the bounds of its first run.
+func (d *Downloader) fetchHistory(ctx context.Context, t Target, oldest, latest string, report func(Event)) (*meta.Checkpoint, error) {
+	cp, err := d.MetaManager.LoadCheckpoint(t.ID)
+	switch {
+	case err != nil:
+		report(Event{Status: fmt.Sprintf("Ignoring unreadable checkpoint: %v", err)})
+		cp = nil
+	case cp != nil && (cp.Oldest != oldest || cp.Latest != latest) && !d.sameRelativeRange(cp):
+		// The cursor belongs to a fetch of a different range
+		report(Event{Status: "Ignoring checkpoint of a different date range..."})
+		cp = nil
+	case cp != nil && cp.Done:
+		report(Event{Current: cp.Messages, Status: fmt.Sprintf("History already fetched (%d messages), saving...", cp.Messages)})
+		return cp, nil
+	case cp != nil:
+		report(Event{Current: cp.Messages, Status: fmt.Sprintf("Resuming from checkpoint (%d messages)...", cp.Messages)})
+	}
+	if cp == nil {
+		cp = d.newCheckpoint(t, oldest, latest)
+	}
+
+	resumed := cp.Pages > 0
+	err = d.fetchPages(ctx, t, cp, report)
+	if err != nil && resumed && strings.Contains(err.Error(), "invalid_cursor") {
+		// Slack cursors expire; start over
+		report(Event{Status: "Checkpoint expired, fetching from the start..."})
+		cp = d.newCheckpoint(t, oldest, latest)
+		err = d.fetchPages(ctx, t, cp, report)
+	}
+	return cp, err
+}
+
+func (d *Downloader) newCheckpoint(t Target, oldest, latest string) *meta.Checkpoint {
+	return &meta.Checkpoint{
+		ChannelID:   t.ID,
+		ChannelName: t.Name,
+		Range:       d.Range.Spec,
+		Oldest:      oldest,
+		Latest:      latest,
+		StartedAt:   time.Now(),
+	}
+}
+
+// sameRelativeRange reports whether cp was written for the relative date
+// range being downloaded, whose bounds have moved since
+func (d *Downloader) sameRelativeRange(cp *meta.Checkpoint) bool {
+	return d.Range.Relative() && cp.Range == d.Range.Spec
+}
+
+// checkpointBounds returns the time range a checkpoint was fetched for
+func checkpointBounds(cp *meta.Checkpoint) (from, until time.Time) {
+	if t, err := slack.ParseTimestamp(cp.Oldest); cp.Oldest != "" && err == nil {
+		from = t
+	}
+	if t, err := slack.ParseTimestamp(cp.Latest); cp.Latest != "" && err == nil {
+		until = t
+	}
+	return from, until
+}
+
+// fetchPages continues the fetch recorded in cp, checkpointing every page
+func (d *Downloader) fetchPages(ctx context.Context, t Target, cp *meta.Checkpoint, report func(Event)) error {
+	progress := func(current, total int, status string) {
+		report(Event{Current: current, Total: total, Status: status})
+	}
+	return slack.FetchHistoryPages(ctx, d.Client, t.ID, d.Retry, progress, cp.Oldest, cp.Latest, cp.Cursor, cp.Messages, func(page []slack.Message, nextCursor string) error {
+		cp.Cursor = nextCursor
+		return d.MetaManager.SaveCheckpointPage(cp, page)
+	})
+}
@@ -0,0 +1,144 @@
+package downloader
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateRange limits a download to messages posted in [Since, Until).
+// A zero bound means the range is open on that side.
+type DateRange struct {
+	Since time.Time
+	Until time.Time
+	Spec  string // The bounds as given, "SINCE..UNTIL", or "" for all history
+}
+
+var (
+	relativePattern = regexp.MustCompile(`^(\d+)([hdwmy])$`)
+	quarterPattern  = regexp.MustCompile(`^(\d{4})-?[Qq]([1-4])$`)
+)
+
+// ParseDateRange parses --since/--until style bounds. Each bound is either
+// absolute (2025-07-01, 2025-07, 2025-Q3, 2025-07-01T09:00 or RFC 3339) or
+// relative to now (48h, 30d, 12w, 6m, 1y). Date-only, month and quarter
+// values are inclusive, so "--since 2025-Q3 --until 2025-Q3" covers the
+// whole quarter.
+func ParseDateRange(since, until string, now time.Time) (DateRange, error) {
+	var r DateRange
+	if since, until = strings.TrimSpace(since), strings.TrimSpace(until); since != "" || until != "" {
+		r.Spec = since + ".." + until
+	}
+	var err error
+	if r.Since, err = parseBound(since, now, false); err != nil {
+		return DateRange{}, fmt.Errorf("invalid since %q: %w", since, err)
+	}
+	if r.Until, err = parseBound(until, now, true); err != nil {
+		return DateRange{}, fmt.Errorf("invalid until %q: %w", until, err)
+	}
+	if !r.Since.IsZero() && !r.Until.IsZero() && !r.Since.Before(r.Until) {
+		return DateRange{}, fmt.Errorf("since (%s) must be before until (%s)", r.Since.Format(time.RFC3339), r.Until.Format(time.RFC3339))
+	}
+	return r, nil
+}
+
+// ParseDateRangeSpec parses a "SINCE..UNTIL" range as typed in the TUI.
+// Either side may be empty; a single value without ".." is a since bound.
+func ParseDateRangeSpec(spec string, now time.Time) (DateRange, error) {
+	since, until, _ := strings.Cut(strings.TrimSpace(spec), "..")
+	return ParseDateRange(since, until, now)
+}
+
+// IsZero reports whether the range is unbounded on both sides
+func (r DateRange) IsZero() bool {
+	return r.Since.IsZero() && r.Until.IsZero()
+}
+
+// Relative reports whether a bound is relative to now, so that it resolves
+// to a different time on every run
+func (r DateRange) Relative() bool {
+	since, until, _ := strings.Cut(r.Spec, "..")
+	return relativePattern.MatchString(since) || relativePattern.MatchString(until)
+}
+
+// String describes the range for progress and confirm screens
+func (r DateRange) String() string {
+	const layout = "2006-01-02 15:04"
+	switch {
+	case r.IsZero():
+		return "all history"
+	case r.Until.IsZero():
+		return "since " + r.Since.Format(layout)
+	case r.Since.IsZero():
+		return "until " + r.Until.Format(layout)
+	}
+	return r.Since.Format(layout) + " → " + r.Until.Format(layout)
+}
+
+// parseBound parses one side of a range. For until bounds, calendar values
+// (day, month, quarter) resolve to the start of the following period.
+func parseBound(s string, now time.Time, until bool) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if m := relativePattern.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "h":
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case "d":
+			return now.AddDate(0, 0, -n), nil
+		case "w":
+			return now.AddDate(0, 0, -7*n), nil
+		case "m":
+			return now.AddDate(0, -n, 0), nil
+		default:
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+
+	if m := quarterPattern.FindStringSubmatch(s); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		quarter, _ := strconv.Atoi(m[2])
+		start := time.Date(year, time.Month(quarter*3-2), 1, 0, 0, 0, 0, time.Local)
+		if until {
+			return start.AddDate(0, 3, 0), nil
+		}
+		return start, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		if until {
+			return t.AddDate(0, 0, 1), nil
+		}
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01", s, time.Local); err == nil {
+		if until {
+			return t.AddDate(0, 1, 0), nil
+		}
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("expected a date (2025-07-01, 2025-07, 2025-Q3) or a duration (30d, 12w, 6m)")
+}
+
+// slackTimestamp formats t as a Slack API timestamp bound
+func slackTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d.000000", t.Unix())
+}
@@ -0,0 +1,104 @@
+package downloader
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDateRange(t *testing.T) {
+	now := time.Date(2025, 8, 15, 12, 30, 0, 0, time.Local)
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name         string
+		since, until string
+		wantSince    time.Time
+		wantUntil    time.Time
+		relative     bool
+	}{
+		{"all history", "", "", time.Time{}, time.Time{}, false},
+		{"hours", "48h", "", now.Add(-48 * time.Hour), time.Time{}, true},
+		{"days", "30d", "", now.AddDate(0, 0, -30), time.Time{}, true},
+		{"weeks", "2w", "1w", now.AddDate(0, 0, -14), now.AddDate(0, 0, -7), true},
+		{"months", "6m", "", now.AddDate(0, -6, 0), time.Time{}, true},
+		{"years", "1y", "", now.AddDate(-1, 0, 0), time.Time{}, true},
+		{"day", "2025-07-01", "2025-07-01", day(2025, 7, 1), day(2025, 7, 2), false},
+		{"month", "2025-07", "2025-07", day(2025, 7, 1), day(2025, 8, 1), false},
+		{"quarter", "2025-Q3", "2025q3", day(2025, 7, 1), day(2025, 10, 1), false},
+		{"last quarter", "2024-Q4", "2024-Q4", day(2024, 10, 1), day(2025, 1, 1), false},
+		{"time of day", "2025-07-01T09:00", "2025-07-01 18:30", day(2025, 7, 1).Add(9 * time.Hour), day(2025, 7, 1).Add(18*time.Hour + 30*time.Minute), false},
+		{"RFC 3339", "2025-07-01T09:00:00Z", "", time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC), time.Time{}, false},
+		{"until only", "", "2025-07", time.Time{}, day(2025, 8, 1), false},
+		{"mixed", " 2025-07 ", "7d", day(2025, 7, 1), now.AddDate(0, 0, -7), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseDateRange(tt.since, tt.until, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !r.Since.Equal(tt.wantSince) || !r.Until.Equal(tt.wantUntil) {
+				t.Errorf("range = %s..%s, want %s..%s", r.Since, r.Until, tt.wantSince, tt.wantUntil)
+			}
+			if r.Relative() != tt.relative {
+				t.Errorf("Relative() = %v, want %v", r.Relative(), tt.relative)
+			}
+			if r.IsZero() != (tt.since == "" && tt.until == "") {
+				t.Errorf("IsZero() = %v", r.IsZero())
+			}
+		})
+	}
+}
+
+func TestParseDateRangeErrors(t *testing.T) {
+	now := time.Date(2025, 8, 15, 12, 30, 0, 0, time.Local)
+	tests := []struct {
+		name         string
+		since, until string
+		want         string
+	}{
+		{"unknown unit", "30x", "", `invalid since "30x"`},
+		{"no number", "d", "", `invalid since "d"`},
+		{"bad quarter", "2025-Q5", "", `invalid since "2025-Q5"`},
+		{"bad date", "", "2025-13-01", `invalid until "2025-13-01"`},
+		{"words", "yesterday", "", `invalid since "yesterday"`},
+		{"reversed", "2025-08", "2025-07", "must be before until"},
+		{"empty range", "2025-07-01T09:00", "2025-07-01T09:00", "must be before until"},
+		{"relative reversed", "1w", "2w", "must be before until"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDateRange(tt.since, tt.until, now)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateRangeSpec(t *testing.T) {
+	now := time.Date(2025, 8, 15, 12, 30, 0, 0, time.Local)
+	tests := []struct {
+		spec      string
+		wantSince bool
+		wantUntil bool
+	}{
+		{"", false, false},
+		{"30d", true, false},
+		{"2025-07..", true, false},
+		{"..2025-07", false, true},
+		{"2025-Q2..2025-Q3", true, true},
+	}
+	for _, tt := range tests {
+		r, err := ParseDateRangeSpec(tt.spec, now)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.spec, err)
+		}
+		if r.Since.IsZero() == tt.wantSince || r.Until.IsZero() == tt.wantUntil {
+			t.Errorf("%q = %s..%s, want since %v and until %v", tt.spec, r.Since, r.Until, tt.wantSince, tt.wantUntil)
+		}
+	}
+}
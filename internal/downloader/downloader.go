@@ -0,0 +1,631 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/config"
+	"github.com/chanseok/slackExtract/internal/export"
+	"github.com/chanseok/slackExtract/internal/manager"
+	"github.com/chanseok/slackExtract/internal/meta"
+	"github.com/chanseok/slackExtract/internal/slack"
+	"github.com/chanseok/slackExtract/internal/store"
+	slackgo "github.com/slack-go/slack"
+)
+
+// Download actions for channels that already exist in the export directory
+const (
+	ActionSkip        = "skip"
+	ActionIncremental = "incremental"
+	ActionOverwrite   = "overwrite"
+)
+
+// Target identifies a channel to download
+type Target struct {
+	ID   string
+	Name string
+}
+
+// Event reports the progress of a single channel download
+type Event struct {
+	ChannelID   string
+	ChannelName string
+	Current     int    // Number of items processed
+	Total       int    // Total items to process (if known)
+	Status      string // Description of current action
+	Done        bool   // True if this channel is finished
+	Skipped     bool   // True if the channel was skipped because it already exists
+	Err         error
+}
+
+// ProgressFunc receives progress events from the downloader
+type ProgressFunc func(Event)
+
+// Downloader runs the fetch → Markdown → metadata pipeline for channels.
+// It is shared by the TUI and the headless CLI.
+type Downloader struct {
+	Client         *slackgo.Client
+	HTTPClient     *http.Client
+	Users          *slack.UserDirectory           // Names of users and bots; bots seen in messages are looked up on demand
+	MetaManager    *meta.Manager                  // Metadata index; also keeps the checkpoints of running downloads
+	Options        export.Options                 // How channel files are rendered
+	ExportRoot     string                         // Root of the export directory (usually "export")
+	TargetFolder   string                         // Folder the channel files are written to
+	Action         string                         // ActionSkip, ActionIncremental or ActionOverwrite
+	ExistingFiles  map[string]manager.ChannelMeta // Result of manager.ScanExportDir, keyed by channel name
+	ThreadLookback time.Duration                  // How far before the last message incremental sync looks for thread activity
+	Range          DateRange                      // Limits the fetched history; zero for all of it
+	Store          *store.Store                   // Raw message store that channel files are rendered from
+	Exporters      []export.Exporter              // Formats channel files are written in; Markdown if empty
+	Retry          slack.RetryConfig
+}
+
+// New creates a downloader with settings taken from the config. All channels
+// downloaded through it share one rate limiter.
+func New(client *slackgo.Client, httpClient *http.Client, users *slack.UserDirectory, cfg *config.Config, metaManager *meta.Manager) *Downloader {
+	retryCfg := slack.DefaultRetryConfig()
+	retryCfg.Limiter = slack.NewRateLimiter()
+	opts := export.NewOptions(cfg)
+	opts.Limiter = retryCfg.Limiter
+	exporters, err := export.Exporters(cfg.ExportFormats)
+	if err != nil {
+		// Load rejects unknown formats; fall back for configs built elsewhere
+		exporters = []export.Exporter{export.Markdown{}}
+	}
+
+	return &Downloader{
+		Client:         client,
+		HTTPClient:     httpClient,
+		Users:          users,
+		MetaManager:    metaManager,
+		Options:        opts,
+		ExportRoot:     "export",
+		TargetFolder:   "export",
+		Action:         ActionSkip,
+		ThreadLookback: time.Duration(cfg.ThreadLookbackDays) * 24 * time.Hour,
+		Store:          store.New("export"),
+		Retry:          retryCfg,
+		Exporters:      exporters,
+	}
+}
+
+// DownloadChannel downloads a single channel and reports its progress.
+// The final event always has Done set; the returned error matches its Err.
+// Fetched pages go to a checkpoint on disk and are merged into the raw
+// store one month at a time, so memory use does not grow with the channel.
+// When ctx is cancelled during the fetch only the checkpointed pages are
+// kept, and the next download of the channel resumes from them; once saving
+// has started the raw store is completed and the Markdown file is either
+// fully replaced or left as it was.
+func (d *Downloader) DownloadChannel(ctx context.Context, t Target, progress ProgressFunc) error {
+	report := func(e Event) {
+		if progress != nil {
+			e.ChannelID = t.ID
+			e.ChannelName = t.Name
+			progress(e)
+		}
+	}
+	fail := func(err error) error {
+		report(Event{Err: err, Done: true})
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fail(fmt.Errorf("cancelled before starting: %w", err))
+	}
+	if d.MetaManager == nil {
+		return fail(fmt.Errorf("no metadata manager to keep checkpoints in"))
+	}
+	report(Event{Status: "Starting..."})
+
+	// Check existing file action
+	if d.Action == ActionSkip {
+		if _, exists := d.ExistingFiles[t.Name]; exists {
+			report(Event{Status: "Skipped (Already exists)", Done: true, Skipped: true})
+			return nil
+		}
+	}
+
+	// The raw store is the source of truth. Fetched history is compared
+	// with it, so edits and deletions are recorded instead of overwritten.
+	hasStore := d.Store.Exists(t.ID)
+
+	// Determine where an incremental download resumes. Without a store
+	// the full history is fetched to seed it.
+	var since time.Time
+	var covered []meta.Span
+	if d.Action == ActionIncremental && hasStore {
+		var err error
+		if since, covered, err = d.resumePoint(t.ID); err != nil {
+			return fail(err)
+		}
+		if !d.Range.Until.IsZero() && !since.Before(d.Range.Until) {
+			report(Event{Status: "Done (already up to date)", Done: true})
+			return nil
+		}
+	} else if d.Action == ActionIncremental {
+		if _, exists := d.ExistingFiles[t.Name]; exists {
+			report(Event{Status: "No raw data stored yet, fetching full history..."})
+		}
+	}
+
+	// Look back further than the last fetched message so that threads
+	// with new replies on older parents (and recent edits) are found too
+	from := d.Range.Since
+	if !since.IsZero() {
+		if windowStart := since.Add(-d.ThreadLookback); windowStart.After(from) {
+			from = windowStart
+		}
+		report(Event{Status: fmt.Sprintf("Incremental from %s...", since.Format("2006-01-02"))})
+	} else if !d.Range.IsZero() {
+		report(Event{Status: fmt.Sprintf("Fetching %s...", d.Range)})
+	}
+
+	// Fetch History with retry support, resuming an interrupted fetch
+	cp, err := d.fetchHistory(ctx, t, slackTimestamp(from), slackTimestamp(d.Range.Until), report)
+	if ctx.Err() != nil {
+		if cp.Messages > 0 {
+			return fail(fmt.Errorf("cancelled, %d messages checkpointed (run again to resume): %w", cp.Messages, ctx.Err()))
+		}
+		return fail(fmt.Errorf("cancelled, nothing saved: %w", ctx.Err()))
+	}
+	if err != nil {
+		if cp.Messages > 0 {
+			return fail(fmt.Errorf("failed to fetch history, %d messages checkpointed (run again to resume): %w", cp.Messages, err))
+		}
+		return fail(fmt.Errorf("failed to fetch history: %w", err))
+	}
+
+	// Record which part of the history is now in the store. Messages
+	// posted after the fetch started are not in it.
+	from, to := checkpointBounds(cp)
+	if to.IsZero() || to.After(cp.StartedAt) {
+		to = cp.StartedAt
+	}
+	covered = append(covered, meta.Span{From: from, To: to})
+
+	// Store new and changed messages and mark the ones deleted in Slack
+	report(Event{Current: cp.Messages, Total: cp.Messages, Status: "Saving raw data..."})
+	stats, err := d.storeFetched(ctx, t.ID, !hasStore, from, to)
+	if err != nil {
+		return fail(err)
+	}
+
+	report(Event{Current: cp.Messages, Total: cp.Messages, Status: "Saving to Markdown & Downloading files..."})
+	if err := d.render(ctx, t, covered, !hasStore); err != nil {
+		if ctx.Err() != nil {
+			return fail(fmt.Errorf("cancelled, raw data saved but Markdown not updated (run again or use render): %w", ctx.Err()))
+		}
+		return fail(err)
+	}
+	if err := d.MetaManager.ClearCheckpoint(t.ID); err != nil {
+		return fail(err)
+	}
+
+	status := "Done"
+	if stats.threads > 0 {
+		status = fmt.Sprintf("Done (%d threads updated)", stats.threads)
+	}
+	if stats.edited > 0 {
+		status += fmt.Sprintf(" (%d edited)", stats.edited)
+	}
+	if stats.deleted > 0 {
+		status += fmt.Sprintf(" (%d deleted)", stats.deleted)
+	}
+	if stats.incomplete > 0 {
+		status += fmt.Sprintf(" (%d incomplete threads)", stats.incomplete)
+	}
+	report(Event{Current: cp.Messages, Total: cp.Messages, Status: status, Done: true})
+	return nil
+}
+
+// SaveChannel replaces a channel's raw store with msgs, renders its Markdown
+// file and updates the metadata index. It is used for messages that come
+// from somewhere other than the Slack API, such as an export archive.
+func (d *Downloader) SaveChannel(ctx context.Context, t Target, msgs []slack.Message) error {
+	if err := d.Store.Reset(t.ID); err != nil {
+		return err
+	}
+	if err := d.Store.Append(t.ID, msgs); err != nil {
+		return err
+	}
+	d.resolveBots(ctx, msgs)
+	return d.render(ctx, t, nil, true)
+}
+
+// resumePoint returns where an incremental download of a stored channel
+// continues: the end of the history fetched without gaps from the start of
+// d.Range. Channels downloaded before coverage was recorded resume after
+// their last stored message; that history is returned as coverage to record.
+func (d *Downloader) resumePoint(channelID string) (time.Time, []meta.Span, error) {
+	if ch, ok := d.MetaManager.GetChannel(channelID); ok && len(ch.Coverage) > 0 {
+		return ch.CoveredUntil(d.Range.Since), nil, nil
+	}
+
+	last, err := d.Store.Last(channelID)
+	if err != nil || last == nil {
+		return time.Time{}, nil, err
+	}
+	lastTime, _ := slack.ParseTimestamp(last.Timestamp)
+	return lastTime, []meta.Span{{To: lastTime}}, nil
+}
+
+// syncStats sums up how a sync changed the stored history of a channel
+type syncStats struct {
+	threads    int // Stored threads with new replies
+	edited     int // Messages and replies edited in Slack
+	deleted    int // Messages and replies deleted in Slack
+	incomplete int // Fetched threads that could not be fetched completely
+}
+
+// storeFetched merges the checkpointed history, which covers [from, to),
+// into the raw store one month at a time (clearing the store first if
+// replace is set)
+func (d *Downloader) storeFetched(ctx context.Context, channelID string, replace bool, from, to time.Time) (syncStats, error) {
+	var stats syncStats
+	pages := d.MetaManager.CheckpointPages()
+
+	if replace {
+		if err := d.Store.Reset(channelID); err != nil {
+			return stats, err
+		}
+	}
+	fetchedMonths, err := pages.Months(channelID)
+	if err != nil {
+		return stats, err
+	}
+	storedMonths, err := d.Store.Months(channelID)
+	if err != nil {
+		return stats, err
+	}
+
+	// Stored months inside the fetched window are checked for deletions
+	// even if nothing was fetched for them
+	months := make(map[string]bool)
+	for _, month := range fetchedMonths {
+		months[month] = true
+	}
+	for _, month := range storedMonths {
+		if monthOverlaps(month, from, to) {
+			months[month] = true
+		}
+	}
+	ordered := make([]string, 0, len(months))
+	for month := range months {
+		ordered = append(ordered, month)
+	}
+	sort.Strings(ordered)
+
+	for _, month := range ordered {
+		fetched, err := pages.LoadMonth(channelID, month)
+		if err != nil {
+			return stats, err
+		}
+		stored, err := d.Store.LoadMonth(channelID, month)
+		if err != nil {
+			return stats, err
+		}
+
+		changes := reconcile(stored, fetched, from, to)
+		if err := d.Store.MarkDeleted(channelID, changes.deleted, changes.deletedReplies); err != nil {
+			return stats, err
+		}
+		if err := d.Store.Append(channelID, changes.save); err != nil {
+			return stats, err
+		}
+		d.resolveBots(ctx, changes.save)
+
+		stats.threads += changes.threads
+		stats.edited += changes.edited
+		stats.deleted += changes.deletedCount()
+		stats.incomplete += countIncomplete(fetched)
+	}
+	return stats, nil
+}
+
+// monthOverlaps reports whether a store segment such as "2025-07" overlaps
+// [from, to). A zero from is unbounded.
+func monthOverlaps(month string, from, to time.Time) bool {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return true
+	}
+	end := start.AddDate(0, 1, 0)
+	return (from.IsZero() || end.After(from)) && start.Before(to)
+}
+
+// render writes the channel's file in every export format from the complete
+// raw store, one month at a time, and updates the metadata index with the
+// written files and the covered history spans (replacing the recorded
+// coverage if replaceCoverage is set)
+func (d *Downloader) render(ctx context.Context, t Target, covered []meta.Span, replaceCoverage bool) error {
+	count, last := 0, ""
+	threads := make(map[string]meta.ThreadStat)
+	msgs := func(fn func(slack.Message) error) error {
+		count, last = 0, ""
+		return d.Store.Each(t.ID, func(msg slack.Message) error {
+			count++
+			last = msg.Timestamp
+			if stat, ok := threadStat(msg); ok {
+				threads[msg.Timestamp] = stat
+			}
+			return fn(msg)
+		})
+	}
+
+	ch := export.Channel{
+		ID:           t.ID,
+		Name:         t.Name,
+		UserMap:      export.UserNames(d.Users, d.Options),
+		Options:      d.Options,
+		HTTPClient:   d.HTTPClient,
+		TargetFolder: d.TargetFolder,
+		Folder:       d.relFolder(),
+	}
+	exporters := d.Exporters
+	if len(exporters) == 0 {
+		exporters = []export.Exporter{export.Markdown{}}
+	}
+	files := make(map[string]string, len(exporters))
+	mainPath := ""
+	for _, e := range exporters {
+		filePath, err := export.Save(ctx, e, ch, msgs)
+		if err != nil {
+			return fmt.Errorf("failed to save %s: %w", e.Format(), err)
+		}
+		files[e.Format()] = d.relPath(filePath)
+		if mainPath == "" || e.Format() == "markdown" {
+			mainPath = files[e.Format()]
+		}
+	}
+
+	// Update Metadata
+	if d.MetaManager != nil && count > 0 {
+		lastMsgTime, _ := slack.ParseTimestamp(last)
+
+		d.MetaManager.UpdateChannelDownload(t.ID, t.Name, mainPath, count, lastMsgTime)
+		for format, relPath := range files {
+			d.MetaManager.RecordChannelFile(t.ID, format, relPath)
+		}
+		d.MetaManager.UpdateChannelThreads(t.ID, threads)
+		d.MetaManager.UpdateChannelCoverage(t.ID, covered, replaceCoverage)
+		if err := d.MetaManager.SaveIndex(); err != nil {
+			return fmt.Errorf("failed to save metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveBots looks up the names of bots that posted without a username.
+// A failed lookup only costs the name, so it does not fail the download.
+func (d *Downloader) resolveBots(ctx context.Context, msgs []slack.Message) {
+	if d.Client == nil || d.Users == nil {
+		return
+	}
+	var botIDs []string
+	for _, msg := range msgs {
+		for _, m := range append([]slackgo.Message{msg.Message}, msg.Replies...) {
+			if m.BotID != "" && m.Username == "" && m.User == "" {
+				botIDs = append(botIDs, m.BotID)
+			}
+		}
+	}
+	d.Users.ResolveBots(ctx, d.Client, d.Retry, botIDs)
+}
+
+// syncChanges is the difference between the stored and the fetched history of a channel
+type syncChanges struct {
+	save           []slack.Message     // New messages and stored ones that changed
+	deleted        []string            // Stored top-level messages no longer in Slack
+	deletedReplies map[string][]string // Parent ts → stored replies no longer in Slack
+	threads        int                 // Stored threads with new replies
+	edited         int                 // Messages and replies edited in Slack
+}
+
+func (c syncChanges) deletedCount() int {
+	count := len(c.deleted)
+	for _, replies := range c.deletedReplies {
+		count += len(replies)
+	}
+	return count
+}
+
+// reconcile compares fetched history, which covers [from, to), with the
+// stored messages. Stored messages in that window that were not fetched
+// again have been deleted in Slack; so have replies missing from a thread
+// that was fetched completely.
+func reconcile(stored, fetched []slack.Message, from, to time.Time) syncChanges {
+	changes := syncChanges{deletedReplies: make(map[string][]string)}
+
+	storedByTS := make(map[string]slack.Message, len(stored))
+	for _, msg := range stored {
+		storedByTS[msg.Timestamp] = msg
+	}
+	fetchedTS := make(map[string]bool, len(fetched))
+
+	for _, msg := range fetched {
+		fetchedTS[msg.Timestamp] = true
+		old, ok := storedByTS[msg.Timestamp]
+		if !ok {
+			changes.save = append(changes.save, msg)
+			continue
+		}
+
+		// A deleted thread parent comes back as a tombstone; the stored
+		// content is kept and only marked deleted
+		changed := false
+		if msg.SubType == "tombstone" {
+			if !old.RevisionOf(old.Timestamp).Deleted {
+				changes.deleted = append(changes.deleted, msg.Timestamp)
+			}
+		} else if store.Changed(old.Message, msg.Message) {
+			changes.edited++
+			changed = true
+		}
+		if msg.LatestReply != old.LatestReply {
+			changes.threads++
+			changed = true
+		}
+		if reactionsChanged(old.Message, msg.Message) {
+			changed = true
+		}
+
+		oldReplies := make(map[string]slackgo.Message, len(old.Replies))
+		for _, r := range old.Replies {
+			oldReplies[r.Timestamp] = r
+		}
+		for _, r := range msg.Replies {
+			if prev, ok := oldReplies[r.Timestamp]; ok {
+				if store.Changed(prev, r) {
+					changes.edited++
+					changed = true
+				} else if reactionsChanged(prev, r) {
+					changed = true
+				}
+				delete(oldReplies, r.Timestamp)
+			}
+		}
+		if msg.ReplyWarning == "" {
+			for ts := range oldReplies {
+				if !old.RevisionOf(ts).Deleted {
+					changes.deletedReplies[msg.Timestamp] = append(changes.deletedReplies[msg.Timestamp], ts)
+				}
+			}
+		}
+
+		if changed {
+			changes.save = append(changes.save, msg)
+		}
+	}
+
+	for _, msg := range stored {
+		if fetchedTS[msg.Timestamp] || msg.RevisionOf(msg.Timestamp).Deleted {
+			continue
+		}
+		msgTime, err := slack.ParseTimestamp(msg.Timestamp)
+		if err != nil || msgTime.Before(from) || !msgTime.Before(to) {
+			continue
+		}
+		changes.deleted = append(changes.deleted, msg.Timestamp)
+	}
+
+	sort.Slice(changes.save, func(i, j int) bool {
+		return changes.save[i].Timestamp < changes.save[j].Timestamp
+	})
+	sort.Strings(changes.deleted)
+	return changes
+}
+
+// reactionsChanged reports whether the emoji reactions of a message differ
+// between two fetches
+func reactionsChanged(older, newer slackgo.Message) bool {
+	if len(older.Reactions) != len(newer.Reactions) {
+		return true
+	}
+	for i, r := range older.Reactions {
+		if r.Name != newer.Reactions[i].Name || r.Count != newer.Reactions[i].Count {
+			return true
+		}
+	}
+	return false
+}
+
+// threadStat returns the fetched vs. expected reply count of a thread parent
+func threadStat(msg slack.Message) (meta.ThreadStat, bool) {
+	if msg.ReplyCount == 0 {
+		return meta.ThreadStat{}, false
+	}
+	return meta.ThreadStat{
+		ReplyCount: msg.ReplyCount,
+		Fetched:    len(msg.Replies),
+		Warning:    msg.ReplyWarning,
+	}, true
+}
+
+// countIncomplete returns the number of threads that were not fetched completely
+func countIncomplete(msgs []slack.Message) int {
+	count := 0
+	for _, msg := range msgs {
+		if stat, ok := threadStat(msg); ok && !stat.Complete() {
+			count++
+		}
+	}
+	return count
+}
+
+// DownloadAll downloads the targets using a pool of workers that share
+// d.Retry (and its rate limiter). progress is called from one goroutine at a
+// time. It returns the number of channels that failed. After ctx is
+// cancelled the remaining channels are reported as failed without being
+// started.
+func (d *Downloader) DownloadAll(ctx context.Context, targets []Target, workers int, progress ProgressFunc) int {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(targets) {
+		workers = len(targets)
+	}
+
+	var (
+		mu     sync.Mutex
+		failed int
+		wg     sync.WaitGroup
+	)
+	report := func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if progress != nil {
+			progress(e)
+		}
+	}
+
+	queue := make(chan Target)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range queue {
+				if err := d.DownloadChannel(ctx, t, report); err != nil {
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, t := range targets {
+		queue <- t
+	}
+	close(queue)
+	wg.Wait()
+
+	return failed
+}
+
+// relFolder returns the target folder relative to the export root, "" for
+// the root itself
+func (d *Downloader) relFolder() string {
+	folder := filepath.ToSlash(d.relPath(d.TargetFolder))
+	if folder == "." {
+		return ""
+	}
+	return folder
+}
+
+// relPath returns the path of a channel file relative to the export root
+func (d *Downloader) relPath(filePath string) string {
+	if d.ExportRoot == "" {
+		return filePath
+	}
+	relPath, err := filepath.Rel(d.ExportRoot, filePath)
+	if err != nil {
+		return filePath
+	}
+	return relPath
+}
@@ -0,0 +1,326 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/config"
+	"github.com/chanseok/slackExtract/internal/manager"
+	"github.com/chanseok/slackExtract/internal/meta"
+	"github.com/chanseok/slackExtract/internal/slack"
+	"github.com/chanseok/slackExtract/internal/slack/fakeslack"
+	slackgo "github.com/slack-go/slack"
+)
+
+// startFake serves ws until the test ends
+func startFake(t *testing.T, ws *fakeslack.Workspace) *fakeslack.Server {
+	t.Helper()
+	srv := fakeslack.New(ws)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newTestDownloader returns a downloader for the fake server that writes to
+// export/ in a temporary working directory. Calls are not paced, so tests
+// only wait for the Retry-After of rate-limited calls.
+func newTestDownloader(t *testing.T, srv *fakeslack.Server, ws *fakeslack.Workspace) *Downloader {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	metaManager, err := meta.NewManager("export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := slack.NewUserDirectory()
+	users.Add(ws.Users...)
+	client := slackgo.New(ws.Token, slackgo.OptionAPIURL(srv.APIURL()))
+	cfg := &config.Config{UserNameStyle: "real", ExportFormats: []string{"markdown"}}
+
+	d := New(client, http.DefaultClient, users, cfg, metaManager)
+	d.Retry.Limiter = nil
+	d.Options.Limiter = nil
+	d.Action = ActionOverwrite
+	d.ExistingFiles = make(map[string]manager.ChannelMeta)
+	return d
+}
+
+// savedChannel returns a channel of the metadata index as saved to disk,
+// or nil if it is not there
+func savedChannel(t *testing.T, id string) *meta.Channel {
+	t.Helper()
+	m, err := meta.NewManager("export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, _ := m.GetChannel(id)
+	return ch
+}
+
+// download runs DownloadAll and fails the test if a channel failed
+func download(t *testing.T, d *Downloader, targets ...Target) []Event {
+	t.Helper()
+	var events []Event
+	failed := d.DownloadAll(context.Background(), targets, 1, func(e Event) {
+		events = append(events, e)
+		if e.Err != nil {
+			t.Errorf("%s: %v", e.ChannelName, e.Err)
+		}
+	})
+	if failed > 0 {
+		t.Fatalf("%d channels failed", failed)
+	}
+	return events
+}
+
+// readFile returns a file written by the test, failing the test if it is missing
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// hasStatus reports whether an event's status contains s
+func hasStatus(events []Event, s string) bool {
+	for _, e := range events {
+		if strings.Contains(e.Status, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestResumeRelativeRange checks that a download with a relative --since
+// resumes the checkpoint of an earlier run, although the bound it resolves
+// to has moved since
+func TestResumeRelativeRange(t *testing.T) {
+	now := time.Now()
+	ws := &fakeslack.Workspace{Token: fakeslack.SampleToken, User: "U00000001"}
+	general := slackgo.Channel{}
+	general.ID, general.Name, general.IsChannel = "C00000001", "general", true
+	ws.Channels = []slackgo.Channel{general}
+	ws.Messages = map[string][]slackgo.Message{general.ID: nil}
+	ws.Users = []slackgo.User{{ID: "U00000001", Name: "alice", RealName: "Alice Kim"}}
+	srv := startFake(t, ws)
+	d := newTestDownloader(t, srv, ws)
+
+	// The first run fetched every page an hour ago and was interrupted
+	// before saving them
+	first, err := ParseDateRange("30d", "", now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := slack.Message{}
+	msg.User, msg.Text = "U00000001", "fetched before the interruption"
+	msg.Timestamp = slackTimestamp(now.AddDate(0, 0, -3))
+	cp := &meta.Checkpoint{ChannelID: general.ID, ChannelName: general.Name, Range: first.Spec, Oldest: slackTimestamp(first.Since)}
+	if err := d.MetaManager.SaveCheckpointPage(cp, []slack.Message{msg}); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Range, err = ParseDateRange("30d", "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := download(t, d, Target{ID: general.ID, Name: general.Name})
+
+	if !hasStatus(events, "History already fetched") {
+		t.Errorf("checkpoint was not resumed: %+v", events)
+	}
+	if calls := srv.Calls("conversations.history"); calls != 0 {
+		t.Errorf("conversations.history called %d times, want 0", calls)
+	}
+	if md := readFile(t, "export/general.md"); !strings.Contains(md, "fetched before the interruption") {
+		t.Errorf("general.md is missing the checkpointed message:\n%s", md)
+	}
+	ch := savedChannel(t, general.ID)
+	if ch == nil || len(ch.Coverage) != 1 || !ch.Coverage[0].From.Equal(first.Since.Truncate(time.Second)) {
+		t.Errorf("coverage = %+v, want it to start at the first run's bound %s", ch, first.Since)
+	}
+}
+
+// sampleTargets are the public channels of fakeslack.SampleWorkspace
+var sampleTargets = []Target{{ID: "C00000001", Name: "general"}, {ID: "C00000002", Name: "random"}}
+
+// TestDownloadSample downloads the sample workspace and checks the
+// channel files and the metadata index
+func TestDownloadSample(t *testing.T) {
+	ws := fakeslack.SampleWorkspace()
+	srv := startFake(t, ws)
+	d := newTestDownloader(t, srv, ws)
+	download(t, d, sampleTargets...)
+
+	general := readFile(t, "export/general.md")
+	for _, want := range []string{
+		"channel_id: \"C00000001\"",
+		"### Alice Kim - ",
+		"Welcome to #general!",
+		":tada: 2 · :+1: 1",
+		"Thread about the release @Alice Kim",
+		"> reply 1\n",
+		"> reply 150\n",
+		"1. **Tag the build**",
+		"### Jira Cloud - ",
+	} {
+		if !strings.Contains(general, want) {
+			t.Errorf("general.md is missing %q", want)
+		}
+	}
+	random := readFile(t, "export/random.md")
+	if !strings.Contains(random, "message 0\n") || !strings.Contains(random, "message 249\n") {
+		t.Errorf("random.md is missing messages of the first or last page")
+	}
+
+	ch := savedChannel(t, "C00000001")
+	if ch == nil {
+		t.Fatal("general is not in the index")
+	}
+	if ch.MessageCount != 9 {
+		t.Errorf("message_count = %d, want 9", ch.MessageCount)
+	}
+	thread, ok := ch.Threads["1700000060.000100"]
+	if !ok || thread.ReplyCount != 150 || thread.Fetched != 150 || !thread.Complete() {
+		t.Errorf("threads = %+v, want the release thread with all 150 replies", ch.Threads)
+	}
+	if len(ch.Coverage) != 1 || !ch.Coverage[0].From.IsZero() {
+		t.Errorf("coverage = %+v, want one span from the start of the channel", ch.Coverage)
+	}
+	if f := ch.Files["markdown"]; f.Path != "general.md" {
+		t.Errorf("files = %+v, want markdown at general.md", ch.Files)
+	}
+	if ch := savedChannel(t, "C00000002"); ch == nil || ch.MessageCount != 250 {
+		t.Errorf("random = %+v, want 250 messages", ch)
+	}
+}
+
+// TestDownloadRateLimited checks that a call answered with 429 is retried
+// after its Retry-After
+func TestDownloadRateLimited(t *testing.T) {
+	ws := fakeslack.SampleWorkspace()
+	srv := startFake(t, ws)
+	srv.PageSize = 100
+	d := newTestDownloader(t, srv, ws)
+
+	srv.RateLimit("conversations.history", 1, 1)
+	start := time.Now()
+	events := download(t, d, Target{ID: "C00000002", Name: "random"})
+
+	// Three pages of history and the rate-limited call
+	if calls := srv.Calls("conversations.history"); calls != 4 {
+		t.Errorf("conversations.history called %d times, want 4", calls)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("download took %s, want it to wait for Retry-After (1s)", elapsed)
+	}
+	if !hasStatus(events, "Done") {
+		t.Errorf("download did not finish: %+v", events)
+	}
+	if random := readFile(t, "export/random.md"); !strings.Contains(random, "message 249\n") {
+		t.Error("random.md is missing the messages of the retried page")
+	}
+}
+
+// TestDownloadSkipAndIncremental checks that existing channels are skipped,
+// and that an incremental download only fetches what is new
+func TestDownloadSkipAndIncremental(t *testing.T) {
+	ws := fakeslack.SampleWorkspace()
+	srv := startFake(t, ws)
+	d := newTestDownloader(t, srv, ws)
+	random := Target{ID: "C00000002", Name: "random"}
+	download(t, d, random)
+	calls := srv.Calls("conversations.history")
+
+	scan, err := manager.ScanExportDir(d.ExportRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.ExistingFiles = scan.Channels
+	d.Action = ActionSkip
+	events := download(t, d, random)
+	if len(events) == 0 || !events[len(events)-1].Skipped {
+		t.Errorf("random was not skipped: %+v", events)
+	}
+	if got := srv.Calls("conversations.history"); got != calls {
+		t.Errorf("skipped channel called conversations.history %d times", got-calls)
+	}
+
+	// A message posted after the first download
+	posted := slackgo.Message{}
+	posted.Type, posted.User, posted.Text = "message", "U00000001", "posted since the last sync"
+	posted.Timestamp = slackTimestamp(time.Now().Add(time.Second))
+	srv.PostMessage(random.ID, posted)
+
+	d.Action = ActionIncremental
+	events = download(t, d, random)
+	if !hasStatus(events, "Incremental from") {
+		t.Errorf("download was not incremental: %+v", events)
+	}
+	if got := srv.Calls("conversations.history") - calls; got != 1 {
+		t.Errorf("incremental download called conversations.history %d times, want 1", got)
+	}
+	md := readFile(t, "export/random.md")
+	if !strings.Contains(md, "posted since the last sync") || !strings.Contains(md, "message 0\n") {
+		t.Error("random.md lost stored messages or is missing the new one")
+	}
+	if ch := savedChannel(t, random.ID); ch == nil || ch.MessageCount != 251 {
+		t.Errorf("random = %+v, want 251 messages", ch)
+	}
+}
+
+// TestDownloadEditsAndDeletes checks that messages edited or deleted in
+// Slack show up as such on the next download
+func TestDownloadEditsAndDeletes(t *testing.T) {
+	ws := fakeslack.SampleWorkspace()
+	srv := startFake(t, ws)
+	d := newTestDownloader(t, srv, ws)
+	general := Target{ID: "C00000001", Name: "general"}
+	download(t, d, general)
+
+	msgs := ws.Messages[general.ID]
+	welcome, reply, former := msgs[0].Timestamp, msgs[5].Timestamp, msgs[len(msgs)-1].Timestamp
+	if !srv.EditMessage(general.ID, welcome, "Welcome, everyone") {
+		t.Fatal("welcome message not found")
+	}
+	if !srv.DeleteMessage(general.ID, reply) || !srv.DeleteMessage(general.ID, former) {
+		t.Fatal("messages to delete not found")
+	}
+
+	events := download(t, d, general)
+	if !hasStatus(events, "(1 edited) (2 deleted)") {
+		t.Errorf("edits and deletions were not counted: %+v", events)
+	}
+	md := readFile(t, "export/general.md")
+	if !strings.Contains(md, "Welcome, everyone *(edited)*") || strings.Contains(md, "Welcome to #general!") {
+		t.Error("general.md does not show the edited text")
+	}
+	// Deleted messages are kept and marked
+	for _, want := range []string{"> reply 4\n\n> 🗑️ *Deleted in Slack*", "Handing over the on-call rotation\n\n🗑️ *Deleted in Slack*"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("general.md is missing %q", want)
+		}
+	}
+}
+
+// TestDownloadEmptyChannel checks that a channel without messages can be
+// downloaded into a new export directory
+func TestDownloadEmptyChannel(t *testing.T) {
+	ws := fakeslack.SampleWorkspace()
+	quiet := slackgo.Channel{}
+	quiet.ID, quiet.Name, quiet.IsChannel = "C00000009", "quiet", true
+	ws.Channels = append(ws.Channels, quiet)
+	ws.Messages[quiet.ID] = nil
+	srv := startFake(t, ws)
+	d := newTestDownloader(t, srv, ws)
+
+	download(t, d, Target{ID: quiet.ID, Name: quiet.Name})
+	if md := readFile(t, "export/quiet.md"); !strings.Contains(md, "channel_id: \"C00000009\"") {
+		t.Errorf("quiet.md has no front matter:\n%s", md)
+	}
+}
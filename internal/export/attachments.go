@@ -0,0 +1,186 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+// permalinkChannel extracts the channel ID from a message permalink
+var permalinkChannel = regexp.MustCompile(`/archives/([A-Z0-9]+)/p\d+`)
+
+// attachmentColors are the labels used for an attachment's colour bar
+var attachmentColors = []struct {
+	label   string
+	r, g, b float64
+}{
+	{"🔴", 0xe0, 0x1e, 0x5a}, // danger
+	{"🟠", 0xf2, 0x8c, 0x28},
+	{"🟡", 0xec, 0xb2, 0x2e}, // warning
+	{"🟢", 0x2e, 0xb6, 0x7d}, // good
+	{"🔵", 0x36, 0xc5, 0xf0},
+	{"🟣", 0x8e, 0x44, 0xad},
+	{"⚪", 0xdd, 0xdd, 0xdd},
+	{"⚫", 0x33, 0x33, 0x33},
+}
+
+// writeAttachments writes legacy attachments (alerts, link unfurls and
+// shared messages) as block quotes under the message
+func writeAttachments(w io.Writer, attachments []slackgo.Attachment, userMap map[string]string, opts Options, indent string) {
+	for _, a := range attachments {
+		lines := attachmentLines(a, userMap, opts)
+		if len(lines) == 0 && a.Pretext == "" {
+			continue
+		}
+		// Pretext is shown above the attachment in Slack, and on its own
+		// when the attachment has nothing else
+		if a.Pretext != "" {
+			for _, line := range strings.Split(cleanSlackText(a.Pretext, userMap), "\n") {
+				fmt.Fprintf(w, "%s%s\n", indent, line)
+			}
+			if len(lines) > 0 {
+				fmt.Fprintf(w, "%s\n", strings.TrimSpace(indent))
+			}
+		}
+		for _, line := range lines {
+			fmt.Fprintf(w, "%s%s\n", indent, strings.TrimSpace("> "+line))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// attachmentLines returns the Markdown lines of one attachment, without the
+// block quote marker
+func attachmentLines(a slackgo.Attachment, userMap map[string]string, opts Options) []string {
+	var lines []string
+
+	// Shared and forwarded messages link back to the original
+	m := permalinkChannel.FindStringSubmatch(a.FromURL)
+	shared := m != nil && (a.AuthorID != "" || a.AuthorName != "")
+	if shared {
+		author := a.AuthorName
+		if name, ok := userMap[a.AuthorID]; ok && name != "" {
+			author = name
+		} else if author == "" {
+			author = a.AuthorSubname
+		}
+		channel := m[1]
+		if name, ok := opts.ChannelNames[channel]; ok && name != "" {
+			channel = name
+		}
+		header := fmt.Sprintf("↪️ Shared message from **%s** in [#%s](%s)", author, channel, a.FromURL)
+		if t, err := slack.ParseTimestamp(string(a.Ts)); err == nil {
+			header += " - " + t.Format(messageTimeLayout)
+		}
+		lines = append(lines, header)
+	} else if a.AuthorName != "" {
+		author := "*" + a.AuthorName + "*"
+		if a.AuthorLink != "" {
+			author = fmt.Sprintf("[%s](%s)", author, a.AuthorLink)
+		}
+		lines = append(lines, author)
+	} else if a.ServiceName != "" && a.Title == "" {
+		lines = append(lines, "*"+a.ServiceName+"*")
+	}
+
+	title := a.Title
+	if title != "" && a.TitleLink != "" {
+		title = fmt.Sprintf("[%s](%s)", title, a.TitleLink)
+	}
+	if title != "" {
+		title = "**" + title + "**"
+	}
+	if label := colorLabel(a.Color); label != "" {
+		title = strings.TrimSpace(label + " " + title)
+	}
+	if title != "" {
+		lines = append(lines, title)
+	}
+
+	text := cleanSlackText(a.Text, userMap)
+	if text == "" && len(a.Blocks.BlockSet) > 0 {
+		text = renderBlocks(a.Blocks.BlockSet, "", userMap, opts.ChannelNames)
+	}
+	if text != "" {
+		lines = append(lines, strings.Split(text, "\n")...)
+	}
+
+	if len(a.Fields) > 0 {
+		lines = append(lines, "", "| Field | Value |", "|---|---|")
+		for _, f := range a.Fields {
+			lines = append(lines, fmt.Sprintf("| %s | %s |", tableCell(cleanSlackText(f.Title, userMap)), tableCell(cleanSlackText(f.Value, userMap))))
+		}
+		lines = append(lines, "")
+	}
+
+	if a.ImageURL != "" {
+		lines = append(lines, fmt.Sprintf("![%s](%s)", a.Title, a.ImageURL))
+	}
+
+	// A shared message's footer only repeats its channel and time
+	footer := ""
+	if !shared {
+		footer = cleanSlackText(a.Footer, userMap)
+	}
+	if t, err := slack.ParseTimestamp(string(a.Ts)); err == nil && footer != "" {
+		footer += " · " + t.Format(messageTimeLayout)
+	}
+	if footer != "" {
+		lines = append(lines, "_"+footer+"_")
+	}
+
+	// Nothing but a colour bar: fall back to the plain-text summary, unless
+	// it only repeats the pretext
+	if len(lines) <= 1 && a.Fallback != "" && text == "" && a.Title == "" && a.Fallback != a.Pretext {
+		lines = []string{strings.TrimSpace(colorLabel(a.Color) + " " + cleanSlackText(a.Fallback, userMap))}
+	}
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// colorLabel returns an emoji close to an attachment's colour bar. Slack
+// accepts the names good, warning and danger as well as hex colours.
+func colorLabel(color string) string {
+	switch color {
+	case "":
+		return ""
+	case "good":
+		return "🟢"
+	case "warning":
+		return "🟡"
+	case "danger":
+		return "🔴"
+	}
+	hex := strings.TrimPrefix(color, "#")
+	if len(hex) != 6 {
+		return ""
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return ""
+	}
+	r, g, b := float64(v>>16&0xff), float64(v>>8&0xff), float64(v&0xff)
+
+	label, best := "", math.MaxFloat64
+	for _, c := range attachmentColors {
+		d := (r-c.r)*(r-c.r) + (g-c.g)*(g-c.g) + (b-c.b)*(b-c.b)
+		if d < best {
+			label, best = c.label, d
+		}
+	}
+	return label
+}
+
+// tableCell escapes a value for a single Markdown table cell
+func tableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "<br>")
+}
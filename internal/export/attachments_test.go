@@ -0,0 +1,9 @@
+package export
+
+import "testing"
+
+// TestAttachmentsGolden renders the messages with legacy attachments in
+// testdata/attachments
+func TestAttachmentsGolden(t *testing.T) {
+	testMessagesGolden(t, "attachments")
+}
@@ -0,0 +1,332 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	slackgo "github.com/slack-go/slack"
+)
+
+// renderBlocks converts a message's Block Kit layout to Markdown.
+// Blocks without readable content (buttons, inputs, ...) are skipped, so the
+// result is empty when the caller should fall back to the message text.
+// text is that message text; blocks mentioning channels or user groups that
+// cannot be named are left to it, unless it is empty.
+func renderBlocks(blocks []slackgo.Block, text string, userMap map[string]string, channelNames map[string]string) string {
+	return renderBlocksWith(blocks, text, blockNames{users: userMap, channels: channelNames})
+}
+
+// blockNames resolves the users and channels mentioned in blocks. If mention
+// is set, it writes the resolved mentions instead of "@name" and "#name".
+type blockNames struct {
+	users    map[string]string
+	channels map[string]string
+	mention  func(Mention) string
+}
+
+func (n blockNames) user(id string) string {
+	name := mentionName(id, n.users)
+	if n.mention == nil {
+		return name
+	}
+	return n.mention(Mention{ID: id, Name: strings.TrimPrefix(name, "@")})
+}
+
+func (n blockNames) channel(id string) string {
+	name := n.channels[id]
+	if name == "" {
+		name = id
+	}
+	if n.mention == nil {
+		return "#" + name
+	}
+	return n.mention(Mention{Channel: true, ID: id, Name: name})
+}
+
+// text converts mrkdwn text of a block
+func (n blockNames) text(text string) string {
+	if n.mention == nil {
+		return cleanSlackText(text, n.users)
+	}
+	return CleanSlackTextFunc(text, n.users, n.channels, n.mention)
+}
+
+// renderBlocksWith is renderBlocks with the mentions written by names
+func renderBlocksWith(blocks []slackgo.Block, text string, names blockNames) string {
+	if strings.TrimSpace(text) != "" && !blocksResolvable(blocks, names.channels) {
+		return ""
+	}
+	var parts []string
+	for _, block := range blocks {
+		if text := renderBlock(block, names); strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// blocksResolvable reports whether every channel and user group mentioned in
+// the rich text of blocks can be shown by name. The message text names them
+// (<#C123|general>, <!subteam^S123|@team>), so it is the better rendering
+// when they cannot.
+func blocksResolvable(blocks []slackgo.Block, channelNames map[string]string) bool {
+	resolvable := func(elements []slackgo.RichTextSectionElement) bool {
+		for _, el := range elements {
+			switch e := el.(type) {
+			case *slackgo.RichTextSectionChannelElement:
+				if channelNames[e.ChannelID] == "" {
+					return false
+				}
+			case *slackgo.RichTextSectionUserGroupElement:
+				return false
+			}
+		}
+		return true
+	}
+	var inList func(list *slackgo.RichTextList) bool
+	inList = func(list *slackgo.RichTextList) bool {
+		for _, el := range list.Elements {
+			switch e := el.(type) {
+			case *slackgo.RichTextSection:
+				if !resolvable(e.Elements) {
+					return false
+				}
+			case *slackgo.RichTextList:
+				if !inList(e) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	for _, block := range blocks {
+		b, ok := block.(*slackgo.RichTextBlock)
+		if !ok {
+			continue
+		}
+		for _, el := range b.Elements {
+			ok := true
+			switch e := el.(type) {
+			case *slackgo.RichTextSection:
+				ok = resolvable(e.Elements)
+			case *slackgo.RichTextQuote:
+				ok = resolvable(e.Elements)
+			case *slackgo.RichTextPreformatted:
+				ok = resolvable(e.Elements)
+			case *slackgo.RichTextList:
+				ok = inList(e)
+			}
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func renderBlock(block slackgo.Block, names blockNames) string {
+	switch b := block.(type) {
+	case *slackgo.HeaderBlock:
+		if b.Text == nil {
+			return ""
+		}
+		return "**" + strings.TrimSpace(b.Text.Text) + "**"
+	case *slackgo.SectionBlock:
+		return renderSection(b, names)
+	case *slackgo.RichTextBlock:
+		return renderRichText(b.Elements, names)
+	case *slackgo.ContextBlock:
+		var parts []string
+		for _, el := range b.ContextElements.Elements {
+			switch e := el.(type) {
+			case *slackgo.TextBlockObject:
+				parts = append(parts, renderTextObject(e, names))
+			case *slackgo.ImageBlockElement:
+				if e.AltText != "" {
+					parts = append(parts, e.AltText)
+				}
+			}
+		}
+		return strings.Join(parts, " · ")
+	case *slackgo.ImageBlock:
+		url := b.ImageURL
+		if url == "" && b.SlackFile != nil {
+			url = b.SlackFile.URL
+		}
+		if url == "" {
+			return ""
+		}
+		alt := b.AltText
+		if b.Title != nil && b.Title.Text != "" {
+			alt = b.Title.Text
+		}
+		return fmt.Sprintf("![%s](%s)", alt, url)
+	case *slackgo.MarkdownBlock:
+		return b.Text
+	case *slackgo.DividerBlock:
+		// "---" is reserved for the separator between messages
+		return "* * *"
+	}
+	return ""
+}
+
+// renderSection writes a section's text followed by its fields as a list
+func renderSection(b *slackgo.SectionBlock, names blockNames) string {
+	var lines []string
+	if b.Text != nil {
+		lines = append(lines, renderTextObject(b.Text, names))
+	}
+	for _, field := range b.Fields {
+		if field == nil {
+			continue
+		}
+		// Fields are usually "*Label*\nValue"; keep each on one list item
+		text := strings.ReplaceAll(renderTextObject(field, names), "\n", " ")
+		lines = append(lines, "- "+text)
+	}
+	if b.Accessory != nil && b.Accessory.ImageElement != nil {
+		img := b.Accessory.ImageElement
+		if img.ImageURL != "" {
+			lines = append(lines, fmt.Sprintf("![%s](%s)", img.AltText, img.ImageURL))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderTextObject returns the Markdown of a plain_text or mrkdwn object
+func renderTextObject(t *slackgo.TextBlockObject, names blockNames) string {
+	if t.Type == slackgo.PlainTextType {
+		return t.Text
+	}
+	return names.text(t.Text)
+}
+
+// renderRichText converts the elements of a rich_text block
+func renderRichText(elements []slackgo.RichTextElement, names blockNames) string {
+	var parts []string
+	for _, el := range elements {
+		var text string
+		switch e := el.(type) {
+		case *slackgo.RichTextSection:
+			text = renderRichTextSection(e.Elements, names)
+		case *slackgo.RichTextList:
+			text = renderRichTextList(e, names)
+		case *slackgo.RichTextQuote:
+			text = renderRichTextSection(e.Elements, names)
+			lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimSpace("> " + line)
+			}
+			text = strings.Join(lines, "\n")
+		case *slackgo.RichTextPreformatted:
+			// Code is shown verbatim, without inline styles
+			var sb strings.Builder
+			for _, se := range e.Elements {
+				switch s := se.(type) {
+				case *slackgo.RichTextSectionTextElement:
+					sb.WriteString(s.Text)
+				case *slackgo.RichTextSectionLinkElement:
+					sb.WriteString(s.URL)
+				default:
+					sb.WriteString(renderRichTextElement(se, names))
+				}
+			}
+			text = "```\n" + strings.TrimRight(sb.String(), "\n") + "\n```"
+		}
+		if text = strings.TrimRight(text, "\n"); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// renderRichTextList writes a (possibly nested) bulleted or numbered list
+func renderRichTextList(list *slackgo.RichTextList, names blockNames) string {
+	indent := strings.Repeat("   ", list.Indent)
+	var lines []string
+	for i, el := range list.Elements {
+		var text string
+		switch e := el.(type) {
+		case *slackgo.RichTextSection:
+			text = renderRichTextSection(e.Elements, names)
+		case *slackgo.RichTextList:
+			lines = append(lines, renderRichTextList(e, names))
+			continue
+		}
+		marker := "- "
+		if list.Style == slackgo.RTEListOrdered {
+			marker = fmt.Sprintf("%d. ", list.Offset+i+1)
+		}
+		text = strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", "\n"+indent+"   ")
+		lines = append(lines, indent+marker+text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderRichTextSection joins the inline elements of a section
+func renderRichTextSection(elements []slackgo.RichTextSectionElement, names blockNames) string {
+	var sb strings.Builder
+	for _, el := range elements {
+		sb.WriteString(renderRichTextElement(el, names))
+	}
+	return sb.String()
+}
+
+func renderRichTextElement(el slackgo.RichTextSectionElement, names blockNames) string {
+	switch e := el.(type) {
+	case *slackgo.RichTextSectionTextElement:
+		return styleText(e.Text, e.Style)
+	case *slackgo.RichTextSectionLinkElement:
+		if e.Text == "" || e.Text == e.URL {
+			return styleText(e.URL, e.Style)
+		}
+		return styleText(fmt.Sprintf("[%s](%s)", e.Text, e.URL), e.Style)
+	case *slackgo.RichTextSectionUserElement:
+		return styleText(names.user(e.UserID), e.Style)
+	case *slackgo.RichTextSectionChannelElement:
+		return styleText(names.channel(e.ChannelID), e.Style)
+	case *slackgo.RichTextSectionUserGroupElement:
+		return "@" + e.UsergroupID
+	case *slackgo.RichTextSectionTeamElement:
+		return e.TeamID
+	case *slackgo.RichTextSectionBroadcastElement:
+		return "@" + e.Range
+	case *slackgo.RichTextSectionEmojiElement:
+		return ":" + e.Name + ":"
+	case *slackgo.RichTextSectionColorElement:
+		return e.Value
+	case *slackgo.RichTextSectionDateElement:
+		if e.Fallback != nil && *e.Fallback != "" {
+			return *e.Fallback
+		}
+		return time.Unix(int64(e.Timestamp), 0).Format(messageTimeLayout)
+	}
+	return ""
+}
+
+// styleText wraps text in Markdown emphasis. Surrounding whitespace is kept
+// outside the markers, since "** bold**" is not bold in Markdown.
+func styleText(text string, style *slackgo.RichTextSectionTextStyle) string {
+	if style == nil || strings.TrimSpace(text) == "" {
+		return text
+	}
+	core := strings.TrimSpace(text)
+	start := strings.Index(text, core)
+	lead, trail := text[:start], text[start+len(core):]
+
+	if style.Code {
+		core = "`" + core + "`"
+	}
+	if style.Strike {
+		core = "~~" + core + "~~"
+	}
+	if style.Italic {
+		core = "_" + core + "_"
+	}
+	if style.Bold {
+		core = "**" + core + "**"
+	}
+	return lead + core + trail
+}
@@ -0,0 +1,87 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files in testdata")
+
+// testUserMap and testChannelNames name the IDs used in testdata
+var (
+	testUserMap      = map[string]string{"U00000001": "Alice Kim", "U00000002": "Bob Lee"}
+	testChannelNames = map[string]string{"C00000001": "general", "C00000002": "random"}
+)
+
+func init() {
+	// Golden files are written in UTC, whatever the zone of the machine
+	time.Local = time.UTC
+}
+
+// readTestMessage reads a message as returned by the Slack API
+func readTestMessage(t *testing.T, path string) slackgo.Message {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg slackgo.Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("failed to parse %s: %v", path, err)
+	}
+	return msg
+}
+
+// checkGolden compares got with the golden file, or rewrites it with -update
+func checkGolden(t *testing.T, golden string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s\n--- got ---\n%s\n--- want ---\n%s", golden, got, want)
+	}
+}
+
+// TestBlocksGolden renders the Block Kit messages in testdata/blocks
+func TestBlocksGolden(t *testing.T) {
+	testMessagesGolden(t, "blocks")
+}
+
+// testMessagesGolden renders every message in testdata/{dir} and compares
+// it with the .md file of the same name
+func testMessagesGolden(t *testing.T, dir string) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", dir, "*.json"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no test messages in testdata/%s: %v", dir, err)
+	}
+	opts := Options{ChannelNames: testChannelNames}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".json")
+		t.Run(name, func(t *testing.T) {
+			msg := slack.Message{Message: readTestMessage(t, input)}
+			var buf bytes.Buffer
+			if err := writeMessage(context.Background(), &buf, msg, testUserMap, nil, "general", opts, t.TempDir(), 0); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, strings.TrimSuffix(input, ".json")+".md", buf.Bytes())
+		})
+	}
+}
@@ -5,9 +5,31 @@ import (
 	"regexp"
 )
 
+// Mention is a user mention or channel link resolved by CleanSlackText
+type Mention struct {
+	Channel bool   // Channel link rather than user mention
+	ID      string // User or channel ID
+	Name    string // Resolved name, without the leading "@" or "#"
+}
+
 // CleanSlackText converts Slack's mrkdwn format to standard Markdown
 // and cleans up the text for better LLM processing.
 func CleanSlackText(text string, userMap map[string]string, channelMap map[string]string) string {
+	return CleanSlackTextFunc(text, userMap, channelMap, nil)
+}
+
+// CleanSlackTextFunc is CleanSlackText with mentions written by format,
+// e.g. as wiki links. A nil format writes "@name" and "#name".
+func CleanSlackTextFunc(text string, userMap map[string]string, channelMap map[string]string, format func(Mention) string) string {
+	if format == nil {
+		format = func(m Mention) string {
+			if m.Channel {
+				return "#" + m.Name
+			}
+			return "@" + m.Name
+		}
+	}
+
 	// 1. User mentions: <@U12345> or <@U12345|username>
 	reUser := regexp.MustCompile(`<@(U[A-Z0-9]+)(?:\|([^>]+))?>`)
 	text = reUser.ReplaceAllStringFunc(text, func(m string) string {
@@ -16,15 +38,15 @@ func CleanSlackText(text string, userMap map[string]string, channelMap map[strin
 			userID := matches[1]
 			// If display name is provided in the mention itself
 			if len(matches) >= 3 && matches[2] != "" {
-				return "@" + matches[2]
+				return format(Mention{ID: userID, Name: matches[2]})
 			}
 			// Look up in userMap
 			if name, ok := userMap[userID]; ok && name != "" {
-				return "@" + name
+				return format(Mention{ID: userID, Name: name})
 			}
 			// Fallback
 			if len(userID) > 4 {
-				return "@Guy" + userID[len(userID)-4:]
+				return format(Mention{ID: userID, Name: "Guy" + userID[len(userID)-4:]})
 			}
 		}
 		return m
@@ -38,16 +60,16 @@ func CleanSlackText(text string, userMap map[string]string, channelMap map[strin
 			channelID := matches[1]
 			// If display name is provided in the link itself
 			if len(matches) >= 3 && matches[2] != "" {
-				return "#" + matches[2]
+				return format(Mention{Channel: true, ID: channelID, Name: matches[2]})
 			}
 			// Look up in channelMap (if provided)
 			if channelMap != nil {
 				if name, ok := channelMap[channelID]; ok && name != "" {
-					return "#" + name
+					return format(Mention{Channel: true, ID: channelID, Name: name})
 				}
 			}
 			// Fallback to ID
-			return "#" + channelID
+			return format(Mention{Channel: true, ID: channelID, Name: channelID})
 		}
 		return m
 	})
@@ -1,6 +1,7 @@
 package export
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,77 +12,198 @@ import (
 	"strings"
 	"time"
 
+	"github.com/chanseok/slackExtract/internal/config"
+	"github.com/chanseok/slackExtract/internal/mdformat"
 	"github.com/chanseok/slackExtract/internal/slack"
+	"github.com/chanseok/slackExtract/internal/version"
 	slackgo "github.com/slack-go/slack"
 )
 
-// SaveToMarkdown saves messages to a Markdown file
-func SaveToMarkdown(httpClient *http.Client, channelName string, msgs []slack.Message, userMap map[string]string, downloadAttachments bool, targetFolder string, appendMode bool) error {
-	// Create target folder if it doesn't exist
-	if err := os.MkdirAll(targetFolder, 0755); err != nil {
-		return fmt.Errorf("failed to create target folder: %w", err)
-	}
+// messageTimeLayout is the time format used in reply headers and edit histories
+const messageTimeLayout = mdformat.DateTimeLayout
+
+// Options controls how messages are rendered
+type Options struct {
+	DownloadAttachments bool            // Download files (or link earlier downloads) instead of linking to Slack
+	ShowEditHistory     bool            // List the earlier versions of edited messages
+	ShowReactionUsers   bool            // List who reacted next to each emoji
+	NameStyle           slack.NameStyle // Which user name is shown
+	MarkDeactivated     bool            // Suffix deactivated users with "(deactivated)"
 
-	// Sanitize channel name for filename
-	safeName := sanitizeFilename(channelName)
-	filePath := filepath.Join(targetFolder, safeName+".md")
+	ChannelNames map[string]string              // Channel ID → name, for messages shared from other channels
+	Channels     map[string]slack.CachedChannel // Channel ID → type, topic and purpose, for front matter
+	Limiter      *slack.RateLimiter             // Paces attachment downloads (optional)
+}
 
-	// Check if file exists when in append mode
-	if appendMode {
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			// File doesn't exist, switch to normal mode
-			appendMode = false
+// NewOptions returns the rendering options configured in the environment,
+// with channel names taken from the local channel cache
+func NewOptions(cfg *config.Config) Options {
+	opts := Options{
+		DownloadAttachments: cfg.DownloadAttachments,
+		ShowEditHistory:     cfg.ShowEditHistory,
+		ShowReactionUsers:   cfg.ShowReactionUsers,
+		NameStyle:           slack.NameStyle(cfg.UserNameStyle),
+		MarkDeactivated:     cfg.MarkDeactivated,
+		ChannelNames:        make(map[string]string),
+		Channels:            make(map[string]slack.CachedChannel),
+	}
+	if channels, err := slack.LoadCachedChannels(); err == nil {
+		for _, ch := range channels {
+			opts.ChannelNames[ch.ID] = ch.Name
+			opts.Channels[ch.ID] = ch
 		}
 	}
+	return opts
+}
 
-	// Open or create file
-	var file *os.File
-	var err error
-	
-	if appendMode {
-		file, err = os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			return fmt.Errorf("failed to open file for append: %w", err)
-		}
-	} else {
-		file, err = os.Create(filePath)
-		if err != nil {
-			return fmt.Errorf("failed to create file: %w", err)
-		}
-		
-		// Write header only for new files
-		fmt.Fprintf(file, "# %s\n\n", channelName)
-		fmt.Fprintf(file, "Exported: %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
-		fmt.Fprintf(file, "---\n\n")
+// UserNames returns the user and bot names to render with, in the style
+// chosen in opts
+func UserNames(users *slack.UserDirectory, opts Options) map[string]string {
+	if users == nil {
+		return make(map[string]string)
 	}
-	defer file.Close()
+	return users.Names(opts.NameStyle, opts.MarkDeactivated)
+}
 
-	// Sort messages from oldest to newest
+// MessageSource calls fn for every message of a channel, oldest first, and
+// stops with the first error fn returns. It lets a channel be written
+// without holding all of its messages in memory.
+type MessageSource func(fn func(slack.Message) error) error
+
+// Messages returns a source over msgs, sorting them from oldest to newest
+func Messages(msgs []slack.Message) MessageSource {
 	sort.Slice(msgs, func(i, j int) bool {
 		return msgs[i].Timestamp < msgs[j].Timestamp
 	})
+	return func(fn func(slack.Message) error) error {
+		for _, msg := range msgs {
+			if err := fn(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SaveToMarkdown renders messages to a channel's Markdown file, replacing
+// any previous version
+func SaveToMarkdown(ctx context.Context, httpClient *http.Client, channelName string, msgs []slack.Message, userMap map[string]string, opts Options, targetFolder string) error {
+	return StreamToMarkdown(ctx, httpClient, channelName, Messages(msgs), userMap, opts, targetFolder)
+}
 
-	// Write messages
-	for _, msg := range msgs {
-		if err := writeMessage(file, msg, userMap, httpClient, channelName, downloadAttachments, targetFolder, 0); err != nil {
-			return fmt.Errorf("failed to write message: %w", err)
+// StreamToMarkdown renders the messages of a source to a channel's Markdown
+// file, replacing any previous version
+func StreamToMarkdown(ctx context.Context, httpClient *http.Client, channelName string, msgs MessageSource, userMap map[string]string, opts Options, targetFolder string) error {
+	_, err := Save(ctx, Markdown{}, Channel{
+		Name:         channelName,
+		UserMap:      userMap,
+		Options:      opts,
+		HTTPClient:   httpClient,
+		TargetFolder: targetFolder,
+	}, msgs)
+	return err
+}
+
+// frontMatter describes the Markdown file of a channel. The message range
+// is read in a pass of its own, as the front matter precedes the messages.
+func frontMatter(ctx context.Context, ch Channel, msgs MessageSource) (mdformat.FrontMatter, error) {
+	fm := mdformat.FrontMatter{
+		ChannelID:       ch.ID,
+		Name:            ch.Name,
+		Folder:          ch.Folder,
+		ExportedAt:      time.Now(),
+		Timezone:        mdformat.LocalZone(),
+		Exporter:        "slack-extract",
+		ExporterVersion: version.String(),
+		FormatVersion:   mdformat.Version,
+	}
+	if info, ok := ch.Options.Channels[ch.ID]; ok {
+		fm.Type = conversationType(info)
+		fm.Private = fm.Type != "public_channel"
+		fm.Topic = info.Topic
+		fm.Purpose = info.Purpose
+	}
+
+	err := msgs(func(msg slack.Message) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if fm.FirstMessageTS == "" {
+			fm.FirstMessageTS = msg.Timestamp
 		}
+		fm.LastMessageTS = msg.Timestamp
+		fm.MessageCount++
+		return nil
+	})
+	return fm, err
+}
 
-		// Write thread replies if any
-		if len(msg.Replies) > 0 {
-			for _, reply := range msg.Replies {
-				replyMsg := slack.Message{Message: reply}
-				if err := writeMessage(file, replyMsg, userMap, httpClient, channelName, downloadAttachments, targetFolder, 1); err != nil {
-					return fmt.Errorf("failed to write reply: %w", err)
-				}
+// conversationType returns the conversation type as named by the Slack API
+func conversationType(ch slack.CachedChannel) string {
+	switch {
+	case ch.IsIM:
+		return "im"
+	case ch.IsMpIM:
+		return "mpim"
+	case ch.IsPrivate || ch.IsGroup:
+		return "private_channel"
+	default:
+		return "public_channel"
+	}
+}
+
+// writeMarkdown writes the header and all messages of a channel
+func writeMarkdown(ctx context.Context, file io.Writer, httpClient *http.Client, channelName string, msgs MessageSource, userMap map[string]string, opts Options, targetFolder string) error {
+	fmt.Fprintf(file, "# %s\n\n", channelName)
+	fmt.Fprintf(file, "Exported: %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(file, "---\n\n")
+
+	// Write messages, grouped under a header per day
+	day := ""
+	return msgs(func(msg slack.Message) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if msgTime, err := slack.ParseTimestamp(msg.Timestamp); err == nil {
+			if d := msgTime.Format(mdformat.DateLayout); d != day {
+				fmt.Fprintf(file, "%s\n\n", mdformat.DateHeader(msgTime))
+				day = d
 			}
 		}
+		return writeThread(ctx, file, msg, userMap, httpClient, channelName, opts, targetFolder)
+	})
+}
+
+// writeThread writes a top-level message followed by its thread replies
+func writeThread(ctx context.Context, w io.Writer, msg slack.Message, userMap map[string]string, httpClient *http.Client, channelName string, opts Options, targetFolder string) error {
+	if err := writeMessage(ctx, w, msg, userMap, httpClient, channelName, opts, targetFolder, 0); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+
+	// Write thread replies if any
+	if len(msg.Replies) > 0 {
+		for _, reply := range msg.Replies {
+			replyMsg := slack.Message{Message: reply, Revisions: msg.Revisions}
+			if err := writeMessage(ctx, w, replyMsg, userMap, httpClient, channelName, opts, targetFolder, 1); err != nil {
+				return fmt.Errorf("failed to write reply: %w", err)
+			}
+		}
+	}
+
+	// Flag threads that could not be fetched completely
+	if msg.ReplyWarning != "" {
+		fmt.Fprintf(w, "> ⚠️ *Incomplete thread: %s*\n\n", msg.ReplyWarning)
 	}
 
 	return nil
 }
 
-func writeMessage(file *os.File, msg slack.Message, userMap map[string]string, httpClient *http.Client, channelName string, downloadAttachments bool, targetFolder string, indentLevel int) error {
+// MarkdownPath returns the path of the Markdown file for a channel
+func MarkdownPath(targetFolder, channelName string) string {
+	return Path(targetFolder, channelName, Markdown{})
+}
+
+func writeMessage(ctx context.Context, file io.Writer, msg slack.Message, userMap map[string]string, httpClient *http.Client, channelName string, opts Options, targetFolder string, indentLevel int) error {
 	// Get user name
 	userName := getUserName(msg.Message, userMap)
 
@@ -91,11 +213,13 @@ func writeMessage(file *os.File, msg slack.Message, userMap map[string]string, h
 		msgTime = time.Now()
 	}
 
-	// Format time
-	timeStr := msgTime.Format("2006-01-02 15:04:05")
-
-	// Clean text
+	// Clean text; Block Kit layouts carry the full content when present
 	text := cleanSlackText(msg.Text, userMap)
+	if len(msg.Blocks.BlockSet) > 0 {
+		if rendered := renderBlocks(msg.Blocks.BlockSet, msg.Text, userMap, opts.ChannelNames); rendered != "" {
+			text = rendered
+		}
+	}
 
 	// Handle indentation for thread replies
 	indent := ""
@@ -105,26 +229,41 @@ func writeMessage(file *os.File, msg slack.Message, userMap map[string]string, h
 
 	// Write message header
 	if indentLevel == 0 {
-		fmt.Fprintf(file, "### %s - %s\n\n", userName, timeStr)
+		fmt.Fprintf(file, "%s\n\n", mdformat.MessageHeader(userName, msgTime))
 	} else {
-		fmt.Fprintf(file, "%s**%s** - %s\n%s\n", indent, userName, timeStr, indent)
+		fmt.Fprintf(file, "%s**%s** - %s\n%s\n", indent, userName, msgTime.Format(messageTimeLayout), indent)
 	}
 
-	// Write message text
-	if text != "" {
-		lines := strings.Split(text, "\n")
-		for _, line := range lines {
-			fmt.Fprintf(file, "%s%s\n", indent, line)
-		}
-		fmt.Fprintln(file)
+	// Write message text, marking edits made in Slack
+	rev := msg.RevisionOf(msg.Timestamp)
+	writeText(file, text, msg.Edited != nil || len(rev.Previous) > 0, indent)
+	if opts.ShowEditHistory && len(rev.Previous) > 0 {
+		writeEditHistory(file, rev.Previous, userMap, indent)
+	}
+	if rev.Deleted {
+		fmt.Fprintf(file, "%s🗑️ *Deleted in Slack*\n\n", indent)
 	}
 
 	// Handle file attachments
 	if len(msg.Files) > 0 {
 		for _, f := range msg.Files {
-			if downloadAttachments {
+			if opts.DownloadAttachments && httpClient == nil {
+				// Offline rendering: link the local copy if it was downloaded before
+				if localPath, ok := localAttachment(f, channelName, targetFolder); ok {
+					if isImage(f.Mimetype) {
+						fmt.Fprintf(file, "%s![%s](%s)\n", indent, f.Name, localPath)
+					} else {
+						fmt.Fprintf(file, "%s📎 [%s](%s)\n", indent, f.Name, localPath)
+					}
+				} else {
+					fmt.Fprintf(file, "%s📎 [%s](%s)\n", indent, f.Name, f.URLPrivate)
+				}
+			} else if opts.DownloadAttachments {
 				// Download file
-				localPath, err := downloadFile(httpClient, f, channelName, targetFolder)
+				localPath, err := downloadFile(ctx, httpClient, opts.Limiter, f, channelName, targetFolder)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
 				if err != nil {
 					fmt.Fprintf(file, "%s📎 [%s](%s) *(download failed)*\n", indent, f.Name, f.URLPrivate)
 				} else {
@@ -143,24 +282,95 @@ func writeMessage(file *os.File, msg slack.Message, userMap map[string]string, h
 		fmt.Fprintln(file)
 	}
 
+	// Alerts, link previews and shared messages
+	if len(msg.Attachments) > 0 {
+		writeAttachments(file, msg.Attachments, userMap, opts, indent)
+	}
+
+	// Emoji reactions, as Slack shows them under the message
+	if len(msg.Reactions) > 0 {
+		writeReactions(file, msg.Reactions, userMap, indent, opts.ShowReactionUsers)
+	}
+
 	// Add separator for top-level messages
 	if indentLevel == 0 {
-		fmt.Fprintln(file, "---\n")
+		fmt.Fprint(file, "---\n\n")
 	}
 
 	return nil
 }
 
+// writeText writes the text of a message, marked "*(edited)*" if it was
+// edited in Slack
+func writeText(w io.Writer, text string, edited bool, indent string) {
+	if text == "" && !edited {
+		return
+	}
+	lines := strings.Split(text, "\n")
+	if edited {
+		// Keep the marker out of a closing code fence
+		if last := lines[len(lines)-1]; strings.HasPrefix(last, "```") {
+			lines = append(lines, "*(edited)*")
+		} else {
+			lines[len(lines)-1] = strings.TrimSpace(last + " *(edited)*")
+		}
+	}
+	for _, line := range lines {
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+	fmt.Fprintln(w)
+}
+
+// writeReactions writes one line with every reaction of a message,
+// e.g. ":+1: 3 · :eyes: 1", optionally followed by who reacted
+func writeReactions(w io.Writer, reactions []slackgo.ItemReaction, userMap map[string]string, indent string, showUsers bool) {
+	parts := make([]string, 0, len(reactions))
+	for _, r := range reactions {
+		part := fmt.Sprintf(":%s: %d", r.Name, r.Count)
+		if showUsers && len(r.Users) > 0 {
+			names := make([]string, 0, len(r.Users))
+			for _, id := range r.Users {
+				names = append(names, getUserName(slackgo.Message{Msg: slackgo.Msg{User: id}}, userMap))
+			}
+			part += " (" + strings.Join(names, ", ") + ")"
+		}
+		parts = append(parts, part)
+	}
+	fmt.Fprintf(w, "%s%s\n\n", indent, strings.Join(parts, " · "))
+}
+
+// writeEditHistory lists the earlier versions of an edited message, oldest first
+func writeEditHistory(w io.Writer, previous []slackgo.Message, userMap map[string]string, indent string) {
+	fmt.Fprintf(w, "%s*Previous versions:*\n", indent)
+	for _, p := range previous {
+		// A version is dated by its own edit, or by the post itself for the original
+		ts := p.Timestamp
+		if p.Edited != nil && p.Edited.Timestamp != "" {
+			ts = p.Edited.Timestamp
+		}
+		timeStr := ""
+		if t, err := slack.ParseTimestamp(ts); err == nil {
+			timeStr = t.Format(messageTimeLayout)
+		}
+		text := strings.ReplaceAll(cleanSlackText(p.Text, userMap), "\n", " ")
+		fmt.Fprintf(w, "%s- %s: %s\n", indent, timeStr, text)
+	}
+	fmt.Fprintln(w)
+}
+
 func getUserName(msg slackgo.Message, userMap map[string]string) string {
 	// Try to get from userMap
 	if name, ok := userMap[msg.User]; ok && name != "" {
 		return name
 	}
 
-	// Try bot username
+	// Try bot username, then the bot's name from bots.info
 	if msg.BotID != "" && msg.Username != "" {
 		return msg.Username
 	}
+	if name, ok := userMap[msg.BotID]; ok && name != "" {
+		return name
+	}
 
 	// Fallback to "Guy XXXX" format
 	if msg.User != "" {
@@ -177,20 +387,22 @@ func cleanSlackText(text string, userMap map[string]string) string {
 	// Replace user mentions <@U123> with real names
 	re := regexp.MustCompile(`<@([A-Z0-9]+)>`)
 	text = re.ReplaceAllStringFunc(text, func(match string) string {
-		userID := match[2 : len(match)-1]
-		if name, ok := userMap[userID]; ok && name != "" {
-			return "@" + name
-		}
-		if len(userID) >= 4 {
-			return "@Guy " + userID[len(userID)-4:]
-		}
-		return "@" + userID
+		return mentionName(match[2:len(match)-1], userMap)
 	})
 
 	// Replace channel links <#C123|general> with #general
 	re = regexp.MustCompile(`<#[A-Z0-9]+\|([^>]+)>`)
 	text = re.ReplaceAllString(text, "#$1")
 
+	// Replace special mentions <!here> and <!subteam^S123|@team> with their label
+	text = plainSpecialRe.ReplaceAllStringFunc(text, func(match string) string {
+		m := plainSpecialRe.FindStringSubmatch(match)
+		if m[2] != "" {
+			return m[2]
+		}
+		return "@" + m[1]
+	})
+
 	// Replace URLs <url|text> with [text](url)
 	re = regexp.MustCompile(`<(https?://[^|>]+)\|([^>]+)>`)
 	text = re.ReplaceAllString(text, "[$2]($1)")
@@ -207,16 +419,41 @@ func cleanSlackText(text string, userMap map[string]string) string {
 	return text
 }
 
-func downloadFile(httpClient *http.Client, file slackgo.File, channelName string, targetFolder string) (string, error) {
-	// Create attachments directory
-	attachDir := filepath.Join(targetFolder, "attachments", channelName)
-	if err := os.MkdirAll(attachDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create attachments directory: %w", err)
+// mentionName returns how a user mention is shown, e.g. "@Alice"
+func mentionName(userID string, userMap map[string]string) string {
+	if name, ok := userMap[userID]; ok && name != "" {
+		return "@" + name
+	}
+	if len(userID) >= 4 {
+		return "@Guy " + userID[len(userID)-4:]
 	}
+	return "@" + userID
+}
 
+// attachmentPath returns where a downloaded file is stored
+func attachmentPath(file slackgo.File, channelName string, targetFolder string) string {
 	// Create unique filename
 	filename := fmt.Sprintf("%s_%s", file.ID, sanitizeFilename(file.Name))
-	filePath := filepath.Join(attachDir, filename)
+	return filepath.Join(targetFolder, "attachments", channelName, filename)
+}
+
+// localAttachment returns the relative path of a previously downloaded file
+func localAttachment(file slackgo.File, channelName string, targetFolder string) (string, bool) {
+	filePath := attachmentPath(file, channelName, targetFolder)
+	if _, err := os.Stat(filePath); err != nil {
+		return "", false
+	}
+	relPath, _ := filepath.Rel(targetFolder, filePath)
+	return relPath, true
+}
+
+func downloadFile(ctx context.Context, httpClient *http.Client, limiter *slack.RateLimiter, file slackgo.File, channelName string, targetFolder string) (string, error) {
+	filePath := attachmentPath(file, channelName, targetFolder)
+
+	// Create attachments directory
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create attachments directory: %w", err)
+	}
 
 	// Check if file already exists
 	if _, err := os.Stat(filePath); err == nil {
@@ -226,12 +463,15 @@ func downloadFile(httpClient *http.Client, file slackgo.File, channelName string
 	}
 
 	// Download file
+	if err := limiter.Wait(ctx, "DownloadFile", nil); err != nil {
+		return "", err
+	}
 	url := file.URLPrivateDownload
 	if url == "" {
 		url = file.URLPrivate
 	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return "", err
 	}
@@ -246,16 +486,22 @@ func downloadFile(httpClient *http.Client, file slackgo.File, channelName string
 		return "", fmt.Errorf("bad status: %s", resp.Status)
 	}
 
-	// Create file
-	out, err := os.Create(filePath)
+	// Write to a temporary file so an interrupted download is not
+	// mistaken for a complete one on the next run
+	tmpPath := filePath + ".tmp"
+	out, err := os.Create(tmpPath)
 	if err != nil {
 		return "", err
 	}
-	defer out.Close()
-
-	// Write content
 	_, err = io.Copy(out, resp.Body)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
 	if err != nil {
+		os.Remove(tmpPath)
 		return "", err
 	}
 
@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Exporter writes the messages of a channel in one output format
+type Exporter interface {
+	// Format is the name of the format as used in EXPORT_FORMATS and --format
+	Format() string
+	// Extension is the file extension, including the dot
+	Extension() string
+	// Export writes the channel's messages, oldest first, to w
+	Export(ctx context.Context, w io.Writer, ch Channel, msgs MessageSource) error
+}
+
+// Channel is the channel an exporter writes, with the settings it is
+// rendered with
+type Channel struct {
+	ID           string
+	Name         string
+	UserMap      map[string]string
+	Options      Options
+	HTTPClient   *http.Client // Downloads attachments; nil only links earlier downloads
+	TargetFolder string       // Folder the file (and downloaded attachments) is written to
+	Folder       string       // TargetFolder relative to the export root, "" for the root
+}
+
+// exporters are the supported formats, keyed by config.ExportFormatNames
+var exporters = map[string]Exporter{
+	"markdown": Markdown{},
+	"json":     JSON{},
+	"jsonl":    JSONL{},
+	"csv":      CSV{},
+	"text":     Text{},
+}
+
+// Exporters returns the exporters of the given formats (see
+// config.ParseFormats), Markdown if none are given
+func Exporters(formats []string) ([]Exporter, error) {
+	if len(formats) == 0 {
+		return []Exporter{Markdown{}}, nil
+	}
+	list := make([]Exporter, 0, len(formats))
+	for _, format := range formats {
+		e, ok := exporters[format]
+		if !ok {
+			return nil, fmt.Errorf("unknown export format %q", format)
+		}
+		list = append(list, e)
+	}
+	return list, nil
+}
+
+// Path returns the file an exporter writes for a channel
+func Path(targetFolder, channelName string, e Exporter) string {
+	// Sanitize channel name for filename
+	return filepath.Join(targetFolder, sanitizeFilename(channelName)+e.Extension())
+}
+
+// Save writes a channel's file in the exporter's format, replacing any
+// previous version, and returns its path. The file is written to a
+// temporary path first so a failure or cancellation never leaves a
+// truncated archive behind.
+func Save(ctx context.Context, e Exporter, ch Channel, msgs MessageSource) (string, error) {
+	// Create target folder if it doesn't exist
+	if err := os.MkdirAll(ch.TargetFolder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create target folder: %w", err)
+	}
+
+	filePath := Path(ch.TargetFolder, ch.Name, e)
+	tmpPath := filePath + ".tmp"
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	w := bufio.NewWriter(file)
+	err = e.Export(ctx, w, ch, msgs)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to replace file: %w", err)
+	}
+	return filePath, nil
+}
+
+// Markdown writes a channel as a readable Markdown document
+type Markdown struct{}
+
+func (Markdown) Format() string    { return "markdown" }
+func (Markdown) Extension() string { return ".md" }
+
+func (Markdown) Export(ctx context.Context, w io.Writer, ch Channel, msgs MessageSource) error {
+	fm, err := frontMatter(ctx, ch, msgs)
+	if err != nil {
+		return err
+	}
+	if err := fm.Write(w); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	return writeMarkdown(ctx, w, ch.HTTPClient, ch.Name, msgs, ch.UserMap, ch.Options, ch.TargetFolder)
+}
@@ -0,0 +1,94 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+// testChannel is a channel with the cases the plain formats handle: threads,
+// reactions, files, edits, deletions, bot attachments and text that needs
+// quoting in CSV
+func testChannel() []slack.Message {
+	msg := func(user, ts, text string) slackgo.Message {
+		m := slackgo.Message{}
+		m.Type, m.User, m.Timestamp, m.Text = "message", user, ts, text
+		return m
+	}
+
+	welcome := slack.Message{Message: msg("U00000001", "1700000000.000100", "Welcome to <#C00000001|general>, <@U00000002>!")}
+	welcome.Reactions = []slackgo.ItemReaction{{Name: "tada", Count: 2, Users: []string{"U00000002", "U00000003"}}}
+
+	thread := slack.Message{Message: msg("U00000002", "1700000060.000100", "Release plan:\n1. tag\n2. deploy")}
+	thread.ThreadTimestamp, thread.ReplyCount = thread.Timestamp, 3
+	edited := msg("U00000001", "1700000120.000100", "LGTM, \"ship it\"")
+	edited.ThreadTimestamp = thread.Timestamp
+	edited.Edited = &slackgo.Edited{User: "U00000001", Timestamp: "1700000130.000000"}
+	removed := msg("U00000002", "1700000180.000100", "wrong thread")
+	removed.ThreadTimestamp = thread.Timestamp
+	thread.Replies = []slackgo.Message{edited, removed}
+	thread.ReplyWarning = "fetched 2 of 3 replies"
+	thread.Revisions = map[string]slack.Revision{removed.Timestamp: {Deleted: true}}
+
+	file := slack.Message{Message: msg("U00000001", "1700000240.000100", "Notes attached")}
+	file.Files = []slackgo.File{{ID: "F00000001", Name: "notes.txt", URLPrivate: "https://files.slack.com/notes.txt"}}
+
+	alert := slack.Message{Message: msg("", "1700000300.000100", "")}
+	alert.SubType, alert.BotID, alert.Username = "bot_message", "B00000001", "PagerDuty"
+	alert.Attachments = []slackgo.Attachment{{Fallback: "Triggered: High error rate on api"}}
+
+	return []slack.Message{welcome, thread, file, alert}
+}
+
+// exportedAt is the export time in the JSON and text exports, which
+// changes on every run
+var exportedAt = regexp.MustCompile(`(Exported: |"exported_at": ")[^"\n]*`)
+
+// TestExportersGolden writes testChannel in every plain format and compares
+// it with testdata/formats
+func TestExportersGolden(t *testing.T) {
+	tests := []struct {
+		exporter Exporter
+		golden   string
+	}{
+		{JSON{}, "general.json"},
+		{JSONL{}, "general.jsonl"},
+		{CSV{}, "general.csv"},
+		{Text{}, "general.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.exporter.Format(), func(t *testing.T) {
+			ch := Channel{
+				ID:           "C00000001",
+				Name:         "general",
+				UserMap:      testUserMap,
+				Options:      Options{ChannelNames: testChannelNames, ShowReactionUsers: true},
+				TargetFolder: t.TempDir(),
+			}
+			var buf bytes.Buffer
+			if err := tt.exporter.Export(context.Background(), &buf, ch, Messages(testChannel())); err != nil {
+				t.Fatal(err)
+			}
+			got := exportedAt.ReplaceAll(buf.Bytes(), []byte("${1}(time)"))
+			checkGolden(t, filepath.Join("testdata", "formats", tt.golden), got)
+		})
+	}
+}
+
+// TestExportersCancelled checks that every exporter stops when ctx is cancelled
+func TestExportersCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, format := range []string{"json", "jsonl", "csv", "text"} {
+		e := exporters[format]
+		ch := Channel{ID: "C00000001", Name: "general", UserMap: testUserMap}
+		if err := e.Export(ctx, &bytes.Buffer{}, ch, Messages(testChannel())); err != context.Canceled {
+			t.Errorf("%s: err = %v, want context.Canceled", format, err)
+		}
+	}
+}
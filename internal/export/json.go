@@ -0,0 +1,258 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+// record is a message or reply in the JSON, JSONL and CSV exports
+type record struct {
+	TS         string           `json:"ts"`
+	Time       string           `json:"time"` // RFC 3339, local time
+	Channel    string           `json:"channel"`
+	ChannelID  string           `json:"channel_id,omitempty"`
+	User       string           `json:"user,omitempty"` // User or bot ID
+	UserName   string           `json:"user_name"`
+	ThreadTS   string           `json:"thread_ts,omitempty"` // Set on thread parents and replies
+	ReplyCount int              `json:"reply_count,omitempty"`
+	Subtype    string           `json:"subtype,omitempty"`
+	Text       string           `json:"text"` // Plain text with mentions and links resolved
+	Reactions  []recordReaction `json:"reactions,omitempty"`
+	Files      []recordFile     `json:"files,omitempty"`
+	Edited     bool             `json:"edited,omitempty"`
+	Deleted    bool             `json:"deleted,omitempty"` // Deleted in Slack after it was archived
+	Warning    string           `json:"warning,omitempty"` // Why the thread is incomplete
+	Replies    []record         `json:"replies,omitempty"` // Only in the JSON export
+}
+
+type recordReaction struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Users []string `json:"users,omitempty"`
+}
+
+type recordFile struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	Path string `json:"path,omitempty"` // Downloaded copy, relative to the export file
+}
+
+// newRecords returns the record of a top-level message and those of its replies
+func newRecords(ctx context.Context, ch Channel, msg slack.Message) (record, []record, error) {
+	parent, err := newRecord(ctx, ch, msg.Message, msg.RevisionOf(msg.Timestamp))
+	if err != nil {
+		return record{}, nil, err
+	}
+	parent.Warning = msg.ReplyWarning
+
+	replies := make([]record, 0, len(msg.Replies))
+	for _, reply := range msg.Replies {
+		r, err := newRecord(ctx, ch, reply, msg.RevisionOf(reply.Timestamp))
+		if err != nil {
+			return record{}, nil, err
+		}
+		if r.ThreadTS == "" {
+			r.ThreadTS = msg.Timestamp
+		}
+		replies = append(replies, r)
+	}
+	return parent, replies, nil
+}
+
+func newRecord(ctx context.Context, ch Channel, msg slackgo.Message, rev slack.Revision) (record, error) {
+	r := record{
+		TS:         msg.Timestamp,
+		Channel:    ch.Name,
+		ChannelID:  ch.ID,
+		User:       msg.User,
+		UserName:   getUserName(msg, ch.UserMap),
+		ThreadTS:   msg.ThreadTimestamp,
+		ReplyCount: msg.ReplyCount,
+		Subtype:    msg.SubType,
+		Text:       plainText(msg, ch.UserMap, ch.Options.ChannelNames),
+		Edited:     msg.Edited != nil || len(rev.Previous) > 0,
+		Deleted:    rev.Deleted,
+	}
+	if r.User == "" {
+		r.User = msg.BotID
+	}
+	if t, err := slack.ParseTimestamp(msg.Timestamp); err == nil {
+		r.Time = t.Format(time.RFC3339)
+	}
+
+	for _, reaction := range msg.Reactions {
+		rr := recordReaction{Name: reaction.Name, Count: reaction.Count}
+		if ch.Options.ShowReactionUsers {
+			for _, id := range reaction.Users {
+				rr.Users = append(rr.Users, strings.TrimPrefix(mentionName(id, ch.UserMap), "@"))
+			}
+		}
+		r.Reactions = append(r.Reactions, rr)
+	}
+
+	for _, f := range msg.Files {
+		path, err := attachmentLink(ctx, ch, f)
+		if err != nil {
+			return record{}, err
+		}
+		r.Files = append(r.Files, recordFile{Name: f.Name, URL: f.URLPrivate, Path: path})
+	}
+	return r, nil
+}
+
+// attachmentLink returns the local copy of a file when attachments are
+// downloaded, fetching it if needed, or "" if there is none. Only a
+// cancelled ctx is an error; a failed download just leaves the file remote.
+func attachmentLink(ctx context.Context, ch Channel, f slackgo.File) (string, error) {
+	if !ch.Options.DownloadAttachments {
+		return "", nil
+	}
+	if ch.HTTPClient == nil {
+		path, _ := localAttachment(f, ch.Name, ch.TargetFolder)
+		return path, nil
+	}
+	path, err := downloadFile(ctx, ch.HTTPClient, ch.Options.Limiter, f, ch.Name, ch.TargetFolder)
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if err != nil {
+		return "", nil
+	}
+	return path, nil
+}
+
+// JSON writes a channel as one JSON document with replies nested under
+// their thread parents
+type JSON struct{}
+
+func (JSON) Format() string    { return "json" }
+func (JSON) Extension() string { return ".json" }
+
+func (JSON) Export(ctx context.Context, w io.Writer, ch Channel, msgs MessageSource) error {
+	header, err := json.Marshal(map[string]string{"id": ch.ID, "name": ch.Name})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "{\n  \"channel\": %s,\n", header)
+	fmt.Fprintf(w, "  \"exported_at\": %q,\n", time.Now().Format(time.RFC3339))
+	fmt.Fprint(w, "  \"messages\": [")
+
+	// Messages are written one at a time so the document is never held in memory
+	first := true
+	err = msgs(func(msg slack.Message) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		parent, replies, err := newRecords(ctx, ch, msg)
+		if err != nil {
+			return err
+		}
+		parent.Replies = replies
+		data, err := json.MarshalIndent(parent, "    ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode message %s: %w", msg.Timestamp, err)
+		}
+		if !first {
+			fmt.Fprint(w, ",")
+		}
+		first = false
+		_, err = fmt.Fprintf(w, "\n    %s", data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if !first {
+		fmt.Fprint(w, "\n  ")
+	}
+	_, err = fmt.Fprint(w, "]\n}\n")
+	return err
+}
+
+// JSONL writes one JSON object per line for every message and reply.
+// Replies follow their parent and carry its ts in thread_ts.
+type JSONL struct{}
+
+func (JSONL) Format() string    { return "jsonl" }
+func (JSONL) Extension() string { return ".jsonl" }
+
+func (JSONL) Export(ctx context.Context, w io.Writer, ch Channel, msgs MessageSource) error {
+	encoder := json.NewEncoder(w)
+	return msgs(func(msg slack.Message) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		parent, replies, err := newRecords(ctx, ch, msg)
+		if err != nil {
+			return err
+		}
+		for _, r := range append([]record{parent}, replies...) {
+			if err := encoder.Encode(r); err != nil {
+				return fmt.Errorf("failed to encode message %s: %w", r.TS, err)
+			}
+		}
+		return nil
+	})
+}
+
+// csvHeader lists the columns of the CSV export
+var csvHeader = []string{"ts", "time", "user", "user_name", "channel", "thread_ts", "text", "reactions", "files"}
+
+// CSV writes one row per message and reply, e.g. for spreadsheets.
+// Reactions are listed as "name:count" and files as "name <url>".
+type CSV struct{}
+
+func (CSV) Format() string    { return "csv" }
+func (CSV) Extension() string { return ".csv" }
+
+func (CSV) Export(ctx context.Context, w io.Writer, ch Channel, msgs MessageSource) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	err := msgs(func(msg slack.Message) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		parent, replies, err := newRecords(ctx, ch, msg)
+		if err != nil {
+			return err
+		}
+		for _, r := range append([]record{parent}, replies...) {
+			if err := cw.Write(csvRow(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvRow(r record) []string {
+	reactions := make([]string, 0, len(r.Reactions))
+	for _, reaction := range r.Reactions {
+		reactions = append(reactions, fmt.Sprintf("%s:%d", reaction.Name, reaction.Count))
+	}
+	files := make([]string, 0, len(r.Files))
+	for _, f := range r.Files {
+		link := f.URL
+		if f.Path != "" {
+			link = f.Path
+		}
+		files = append(files, fmt.Sprintf("%s <%s>", f.Name, link))
+	}
+	return []string{r.TS, r.Time, r.User, r.UserName, r.Channel, r.ThreadTS, r.Text, strings.Join(reactions, " "), strings.Join(files, "; ")}
+}
@@ -0,0 +1,580 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/chanseok/slackExtract/internal/mdformat"
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+// VaultSplit selects how a channel is divided into notes
+type VaultSplit string
+
+const (
+	VaultByChannel VaultSplit = "channel" // One note per channel
+	VaultByMonth   VaultSplit = "month"   // One note per channel and month, listed in a channel note
+)
+
+// VaultChannel is a channel added to a Vault
+type VaultChannel struct {
+	Info         slack.CachedChannel // Name, type, topic and purpose of the channel
+	Folder       string              // Subfolder under the export root, "" for the root
+	TargetFolder string              // Folder the channel was exported to, holding downloaded attachments
+	Months       []string            // Stored months (YYYY-MM), oldest first
+	Load         func(month string) ([]slack.Message, error)
+}
+
+// Vault writes an Obsidian vault: a note per channel (or per channel and
+// month) in which users and channels are [[wiki links]], a note per person
+// who posted or was mentioned, and downloaded attachments under assets/.
+// Notes are tagged with the channel type and export folder, so the graph
+// view connects people to the channels they talk in.
+type Vault struct {
+	dir      string
+	split    VaultSplit
+	users    *slack.UserDirectory
+	userMap  map[string]string
+	opts     Options
+	people   map[string]string         // User or bot ID → person note name
+	channels map[string]string         // Channel ID → note of the channels in the vault
+	labels   map[string]string         // Channel note → "#name"
+	activity map[string]map[string]int // User or bot ID → channel note → messages posted
+	linked   map[string]bool           // IDs whose person note is written on Close
+	index    []vaultIndexEntry
+}
+
+// vaultIndexEntry is a channel in the vault's home note
+type vaultIndexEntry struct {
+	Folder   string
+	Note     string
+	Label    string
+	Messages int
+}
+
+// NewVault starts a vault in dir holding the given channels, which are
+// added one by one with AddChannel. Notes of earlier runs are overwritten.
+func NewVault(dir string, users *slack.UserDirectory, opts Options, split VaultSplit, channels []VaultChannel) (*Vault, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create vault folder: %w", err)
+	}
+	v := &Vault{
+		dir:      dir,
+		split:    split,
+		users:    users,
+		userMap:  UserNames(users, opts),
+		opts:     opts,
+		people:   make(map[string]string),
+		channels: make(map[string]string),
+		labels:   make(map[string]string),
+		activity: make(map[string]map[string]int),
+		linked:   make(map[string]bool),
+	}
+
+	// Person notes are named without the "(deactivated)" mark. People
+	// sharing a name get their ID appended, so every note name is unique.
+	names := users.Names(opts.NameStyle, false)
+	taken := make(map[string]int)
+	for _, name := range names {
+		taken[strings.ToLower(noteName(name))]++
+	}
+	for id, name := range names {
+		note := noteName(name)
+		if note == "" || taken[strings.ToLower(note)] > 1 {
+			note = strings.TrimSpace(note + " (" + id + ")")
+		}
+		v.people[id] = note
+	}
+
+	// Channels are linked only when they are part of the vault
+	for _, ch := range channels {
+		note := v.channelNote(ch.Info)
+		v.channels[ch.Info.ID] = note
+		v.labels[note] = siteChannelLabel(ch.Info)
+	}
+	return v, nil
+}
+
+// AddChannel writes the notes of a channel, one month at a time
+func (v *Vault) AddChannel(ctx context.Context, ch VaultChannel) error {
+	note, ok := v.channels[ch.Info.ID]
+	if !ok {
+		note = v.channelNote(ch.Info)
+		v.channels[ch.Info.ID] = note
+		v.labels[note] = siteChannelLabel(ch.Info)
+	}
+	entry := vaultIndexEntry{Folder: ch.Folder, Note: note, Label: v.labels[note]}
+
+	var err error
+	if v.split == VaultByMonth {
+		entry.Messages, err = v.writeMonths(ctx, ch, note)
+	} else {
+		entry.Messages, err = v.writeChannel(ctx, ch, note)
+	}
+	if err != nil {
+		return err
+	}
+	v.index = append(v.index, entry)
+	return nil
+}
+
+// writeChannel writes all messages of a channel to one note
+func (v *Vault) writeChannel(ctx context.Context, ch VaultChannel, note string) (int, error) {
+	msgs := func(fn func(slack.Message) error) error {
+		for _, month := range ch.Months {
+			monthMsgs, err := ch.Load(month)
+			if err != nil {
+				return err
+			}
+			if err := Messages(monthMsgs)(fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	fm, err := v.frontMatter(ctx, ch, msgs)
+	if err != nil {
+		return 0, err
+	}
+
+	err = v.writeNote(note, func(w io.Writer) error {
+		if err := fm.Write(w); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\n# %s\n\n", v.labels[note])
+		v.writeAbout(w, ch)
+		fmt.Fprint(w, "---\n\n")
+		return v.writeMessages(ctx, w, ch, note, msgs)
+	})
+	return fm.MessageCount, err
+}
+
+// writeMonths writes a note per month and a channel note listing them
+func (v *Vault) writeMonths(ctx context.Context, ch VaultChannel, note string) (int, error) {
+	label := v.labels[note]
+	total := mdformat.FrontMatter{}
+	var months []string
+
+	for i, month := range ch.Months {
+		monthMsgs, err := ch.Load(month)
+		if err != nil {
+			return 0, err
+		}
+		fm, err := v.frontMatter(ctx, ch, Messages(monthMsgs))
+		if err != nil {
+			return 0, err
+		}
+		fm.Aliases = []string{label + " " + month}
+
+		err = v.writeNote(v.monthNote(note, month), func(w io.Writer) error {
+			if err := fm.Write(w); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "\n# %s · %s\n\n", label, month)
+			nav := []string{wikiLink(note, label)}
+			if i > 0 {
+				nav = append([]string{"← " + wikiLink(v.monthNote(note, ch.Months[i-1]), ch.Months[i-1])}, nav...)
+			}
+			if i < len(ch.Months)-1 {
+				nav = append(nav, wikiLink(v.monthNote(note, ch.Months[i+1]), ch.Months[i+1])+" →")
+			}
+			fmt.Fprintf(w, "%s\n\n---\n\n", strings.Join(nav, " · "))
+			return v.writeMessages(ctx, w, ch, note, Messages(monthMsgs))
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		months = append(months, fmt.Sprintf("- %s (%d messages)", wikiLink(v.monthNote(note, month), month), fm.MessageCount))
+		if total.FirstMessageTS == "" {
+			total.FirstMessageTS = fm.FirstMessageTS
+		}
+		if fm.LastMessageTS != "" {
+			total.LastMessageTS = fm.LastMessageTS
+		}
+		total.MessageCount += fm.MessageCount
+	}
+
+	fm, err := v.frontMatter(ctx, ch, Messages(nil))
+	if err != nil {
+		return 0, err
+	}
+	fm.FirstMessageTS, fm.LastMessageTS, fm.MessageCount = total.FirstMessageTS, total.LastMessageTS, total.MessageCount
+	err = v.writeNote(note, func(w io.Writer) error {
+		if err := fm.Write(w); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\n# %s\n\n", label)
+		v.writeAbout(w, ch)
+		fmt.Fprint(w, "## Months\n\n")
+		for _, line := range months {
+			fmt.Fprintln(w, line)
+		}
+		return nil
+	})
+	return total.MessageCount, err
+}
+
+// frontMatter describes a channel note, tagged with the channel's type and
+// export folder
+func (v *Vault) frontMatter(ctx context.Context, ch VaultChannel, msgs MessageSource) (mdformat.FrontMatter, error) {
+	fm, err := frontMatter(ctx, Channel{ID: ch.Info.ID, Name: ch.Info.Name, Folder: ch.Folder, Options: v.opts}, msgs)
+	if err != nil {
+		return fm, err
+	}
+	// The vault has the channel details even when the channel cache does not
+	fm.Type = conversationType(ch.Info)
+	fm.Private = fm.Type != "public_channel"
+	fm.Topic = ch.Info.Topic
+	fm.Purpose = ch.Info.Purpose
+
+	fm.Tags = []string{"slack/" + fm.Type}
+	if ch.Info.IsArchived {
+		fm.Tags = append(fm.Tags, "slack/archived")
+	}
+	if ch.Folder != "" {
+		fm.Tags = append(fm.Tags, "slack/folder/"+tagName(ch.Folder))
+	}
+	fm.Aliases = []string{siteChannelLabel(ch.Info)}
+	return fm, nil
+}
+
+// writeAbout writes the topic and purpose of a channel, or the other
+// participant of a DM
+func (v *Vault) writeAbout(w io.Writer, ch VaultChannel) {
+	if ch.Info.IsIM && ch.Info.User != "" {
+		fmt.Fprintf(w, "Direct messages with %s\n\n", v.personLink(ch.Info.User, v.userName(ch.Info.User)))
+	}
+	if ch.Info.Topic != "" {
+		fmt.Fprintf(w, "**Topic:** %s\n\n", v.cleanText(ch.Info.Topic))
+	}
+	if ch.Info.Purpose != "" {
+		fmt.Fprintf(w, "**Purpose:** %s\n\n", v.cleanText(ch.Info.Purpose))
+	}
+}
+
+// writeMessages writes messages grouped under a header per day, as in
+// exported channel files
+func (v *Vault) writeMessages(ctx context.Context, w io.Writer, ch VaultChannel, note string, msgs MessageSource) error {
+	day := ""
+	return msgs(func(msg slack.Message) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if msgTime, err := slack.ParseTimestamp(msg.Timestamp); err == nil {
+			if d := msgTime.Format(mdformat.DateLayout); d != day {
+				fmt.Fprintf(w, "%s\n\n", mdformat.DateHeader(msgTime))
+				day = d
+			}
+		}
+		if err := v.writeMessage(w, ch, note, msg.Message, msg.RevisionOf(msg.Timestamp), ""); err != nil {
+			return err
+		}
+		for _, reply := range msg.Replies {
+			if err := v.writeMessage(w, ch, note, reply, msg.RevisionOf(reply.Timestamp), "> "); err != nil {
+				return err
+			}
+		}
+		if msg.ReplyWarning != "" {
+			fmt.Fprintf(w, "> ⚠️ *Incomplete thread: %s*\n\n", msg.ReplyWarning)
+		}
+		_, err := fmt.Fprint(w, "---\n\n")
+		return err
+	})
+}
+
+// writeMessage writes a message, or a thread reply when indent is set
+func (v *Vault) writeMessage(w io.Writer, ch VaultChannel, note string, msg slackgo.Message, rev slack.Revision, indent string) error {
+	authorID := msg.User
+	if authorID == "" {
+		authorID = msg.BotID
+	}
+	if authorID != "" {
+		if v.activity[authorID] == nil {
+			v.activity[authorID] = make(map[string]int)
+		}
+		v.activity[authorID][note]++
+	}
+	author := v.personLink(authorID, getUserName(msg, v.userMap))
+
+	msgTime, err := slack.ParseTimestamp(msg.Timestamp)
+	if err != nil {
+		msgTime = time.Now()
+	}
+	if indent == "" {
+		fmt.Fprintf(w, "%s\n\n", mdformat.MessageHeader(author, msgTime))
+	} else {
+		fmt.Fprintf(w, "%s**%s** - %s\n%s\n", indent, author, msgTime.Format(messageTimeLayout), indent)
+	}
+
+	// As in channel files, Block Kit layouts carry the full content when present
+	text := v.cleanText(msg.Text)
+	if len(msg.Blocks.BlockSet) > 0 {
+		names := blockNames{users: v.userMap, channels: v.opts.ChannelNames, mention: v.mention}
+		if rendered := renderBlocksWith(msg.Blocks.BlockSet, msg.Text, names); rendered != "" {
+			text = rendered
+		}
+	}
+	writeText(w, text, msg.Edited != nil || len(rev.Previous) > 0, indent)
+	if v.opts.ShowEditHistory && len(rev.Previous) > 0 {
+		writeEditHistory(w, rev.Previous, v.userMap, indent)
+	}
+	if rev.Deleted {
+		fmt.Fprintf(w, "%s🗑️ *Deleted in Slack*\n\n", indent)
+	}
+
+	if len(msg.Files) > 0 {
+		for _, f := range msg.Files {
+			link, err := v.fileLink(ch, f)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s%s\n", indent, link)
+		}
+		fmt.Fprintln(w)
+	}
+	if len(msg.Attachments) > 0 {
+		writeAttachments(w, msg.Attachments, v.userMap, v.opts, indent)
+	}
+	if len(msg.Reactions) > 0 {
+		writeReactions(w, msg.Reactions, v.userMap, indent, v.opts.ShowReactionUsers)
+	}
+	return nil
+}
+
+// fileLink embeds a downloaded attachment, copied to assets/, or links the
+// file in Slack when it was not downloaded
+func (v *Vault) fileLink(ch VaultChannel, f slackgo.File) (string, error) {
+	local, ok := localAttachment(f, ch.Info.Name, ch.TargetFolder)
+	if !ok {
+		return fmt.Sprintf("📎 [%s](%s)", f.Name, f.URLPrivate), nil
+	}
+	asset := "assets/" + v.channelSlug(ch.Info) + "/" + noteName(filepath.Base(local))
+	if err := copyFile(filepath.Join(ch.TargetFolder, local), filepath.Join(v.dir, filepath.FromSlash(asset))); err != nil {
+		return "", err
+	}
+	if isImage(f.Mimetype) {
+		return "![[" + asset + "]]", nil
+	}
+	return "📎 " + wikiLink(asset, f.Name), nil
+}
+
+// cleanText converts Slack text to Markdown with mentions as wiki links
+func (v *Vault) cleanText(text string) string {
+	return CleanSlackTextFunc(text, v.userMap, v.opts.ChannelNames, v.mention)
+}
+
+func (v *Vault) mention(m Mention) string {
+	if m.Channel {
+		if note, ok := v.channels[m.ID]; ok {
+			return wikiLink(note, "#"+m.Name)
+		}
+		return "#" + m.Name
+	}
+	return v.personLink(m.ID, "@"+m.Name)
+}
+
+// personLink links to the person note of a user or bot, or returns the
+// label alone if the ID is not in the user directory
+func (v *Vault) personLink(id, label string) string {
+	note, ok := v.people[id]
+	if !ok {
+		return label
+	}
+	v.linked[id] = true
+	return wikiLink("People/"+note, label)
+}
+
+func (v *Vault) userName(id string) string {
+	return getUserName(slackgo.Message{Msg: slackgo.Msg{User: id}}, v.userMap)
+}
+
+// Close writes the person notes and the vault's home note, Slack.md
+func (v *Vault) Close() error {
+	ids := make([]string, 0, len(v.linked))
+	for id := range v.linked {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return strings.ToLower(v.people[ids[i]]) < strings.ToLower(v.people[ids[j]])
+	})
+	for _, id := range ids {
+		if err := v.writePerson(id); err != nil {
+			return err
+		}
+	}
+
+	sort.SliceStable(v.index, func(i, j int) bool {
+		if v.index[i].Folder != v.index[j].Folder {
+			return v.index[i].Folder < v.index[j].Folder
+		}
+		return v.index[i].Label < v.index[j].Label
+	})
+	return v.writeNote("Slack", func(w io.Writer) error {
+		fmt.Fprint(w, "# Slack\n\n## Channels\n")
+		folder := "-"
+		for _, entry := range v.index {
+			if entry.Folder != folder {
+				folder = entry.Folder
+				if folder != "" {
+					fmt.Fprintf(w, "\n### %s\n", folder)
+				}
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "- %s (%d messages)\n", wikiLink(entry.Note, entry.Label), entry.Messages)
+		}
+		fmt.Fprint(w, "\n## People\n\n")
+		for _, id := range ids {
+			fmt.Fprintf(w, "- %s\n", wikiLink("People/"+v.people[id], v.people[id]))
+		}
+		return nil
+	})
+}
+
+// writePerson writes the note of a user or bot, listing the channels they
+// posted in
+func (v *Vault) writePerson(id string) error {
+	name := v.users.Name(id, v.opts.NameStyle)
+
+	// Channels the person posted in, busiest first
+	notes := make([]string, 0, len(v.activity[id]))
+	for note := range v.activity[id] {
+		notes = append(notes, note)
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		a, b := v.activity[id][notes[i]], v.activity[id][notes[j]]
+		if a != b {
+			return a > b
+		}
+		return v.labels[notes[i]] < v.labels[notes[j]]
+	})
+
+	return v.writeNote("People/"+v.people[id], func(w io.Writer) error {
+		// JSON values are valid YAML, so strings never need escaping rules of their own
+		field := func(key string, value any) {
+			data, _ := json.Marshal(value)
+			fmt.Fprintf(w, "%s: %s\n", key, data)
+		}
+		fmt.Fprintln(w, "---")
+		field("slack_id", id)
+		tag, about := "slack/person", ""
+		if u, ok := v.users.Lookup(id); ok {
+			if u.IsBot {
+				tag = "slack/bot"
+			}
+			about = u.Profile.Title
+			field("handle", u.Name)
+			field("real_name", u.RealName)
+			field("display_name", u.Profile.DisplayName)
+			field("title", u.Profile.Title)
+			field("timezone", u.TZ)
+			field("deactivated", u.Deleted)
+		} else if b, ok := v.users.LookupBot(id); ok {
+			tag = "slack/bot"
+			field("deactivated", b.Deleted)
+		}
+		field("tags", []string{tag})
+		field("aliases", []string{"@" + name})
+		fmt.Fprintf(w, "---\n\n# %s\n\n", name)
+
+		if avatar := v.users.Avatar(id); avatar != "" {
+			fmt.Fprintf(w, "![%s|72](%s)\n\n", initials(name), avatar)
+		}
+		if about != "" {
+			fmt.Fprintf(w, "*%s*\n\n", about)
+		}
+		if len(notes) > 0 {
+			fmt.Fprint(w, "## Channels\n\n")
+			for _, note := range notes {
+				fmt.Fprintf(w, "- %s (%d messages)\n", wikiLink(note, v.labels[note]), v.activity[id][note])
+			}
+		}
+		return nil
+	})
+}
+
+// writeNote writes the note at a vault path without ".md", creating its folder
+func (v *Vault) writeNote(note string, write func(w io.Writer) error) error {
+	path := filepath.Join(v.dir, filepath.FromSlash(note)+".md")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create note folder: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create note: %w", err)
+	}
+	w := bufio.NewWriter(file)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// channelNote returns the vault path of a channel's note, e.g.
+// "Channels/general", or "Channels/general/general" when split by month
+func (v *Vault) channelNote(ch slack.CachedChannel) string {
+	slug := v.channelSlug(ch)
+	if v.split == VaultByMonth {
+		return "Channels/" + slug + "/" + slug
+	}
+	return "Channels/" + slug
+}
+
+// monthNote returns the vault path of a channel's note for one month
+func (v *Vault) monthNote(channelNote, month string) string {
+	return channelNote + " " + month
+}
+
+func (v *Vault) channelSlug(ch slack.CachedChannel) string {
+	if slug := noteName(ch.Name); slug != "" {
+		return slug
+	}
+	return ch.ID
+}
+
+// noteName makes a name safe as a file name and as a wiki link target
+func noteName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|#^[]`, r) || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, name)
+	// Names starting with a dot would be hidden files
+	return strings.TrimLeft(strings.TrimSpace(name), ".")
+}
+
+// wikiLink returns "[[target|label]]", dropping the characters that would
+// end the link early from the label
+func wikiLink(target, label string) string {
+	label = strings.NewReplacer("[", "", "]", "", "|", "").Replace(label)
+	return "[[" + target + "|" + label + "]]"
+}
+
+// tagName turns a folder name into an Obsidian tag, which cannot contain
+// spaces or punctuation other than "-", "_" and "/"
+func tagName(folder string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '/' {
+			return r
+		}
+		return '-'
+	}, filepath.ToSlash(folder))
+}
@@ -0,0 +1,47 @@
+package export
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+// TestVaultMessageLinks checks that vault notes render a message like the
+// channel file does, with mentions as wiki links
+func TestVaultMessageLinks(t *testing.T) {
+	users := slack.NewUserDirectory()
+	users.Add(slackgo.User{ID: "U00000001", RealName: "Alice Kim"}, slackgo.User{ID: "U00000002", RealName: "Bob Lee"})
+	opts := Options{ChannelNames: testChannelNames}
+	general := VaultChannel{Info: slack.CachedChannel{ID: "C00000001", Name: "general", IsChannel: true}}
+	v, err := NewVault(t.TempDir(), users, opts, VaultByChannel, []VaultChannel{general})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// Rich text blocks are preferred over the text, as in channel files
+		{"channel_mention", "See [[Channels/general|#general]] and ask [[People/Bob Lee|@Bob Lee]]\n"},
+		{"formatted", "1. **Tag the build**\n"},
+		// Channels outside the vault stay plain text
+		{"unknown_channel", "Moved to #secret-project\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			msg := readTestMessage(t, filepath.Join("testdata", "blocks", tt.input+".json"))
+			var buf bytes.Buffer
+			if err := v.writeMessage(&buf, general, "Channels/general", msg, slack.Revision{}, ""); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("note =\n%s\nwant it to contain %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
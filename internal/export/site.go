@@ -0,0 +1,653 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+//go:embed siteassets
+var siteAssets embed.FS
+
+var siteTemplates = template.Must(template.ParseFS(siteAssets, "siteassets/*.html"))
+
+// siteSearchTextLimit caps the text of a message in the search index
+const siteSearchTextLimit = 1000
+
+// SiteChannel is a channel added to a Site
+type SiteChannel struct {
+	Info         slack.CachedChannel // Name, topic and purpose shown on its pages
+	TargetFolder string              // Folder the channel was exported to, holding downloaded attachments
+	Months       []string            // Stored months (YYYY-MM), oldest first
+	Load         func(month string) ([]slack.Message, error)
+}
+
+// Site writes a static HTML archive: an index of channels, one page per
+// channel and month with collapsible threads and a permalink per message,
+// and a search page that runs in the browser. Downloaded attachments are
+// copied next to the pages, so the folder can be opened straight from disk
+// or shared as is. Authors are shown with their initials instead of their
+// avatars, so the pages load nothing from Slack.
+type Site struct {
+	dir          string
+	userMap      map[string]string
+	channelNames map[string]string
+	search       *os.File
+	searchW      *bufio.Writer
+	entries      int
+	channels     []siteIndexEntry
+}
+
+// NewSite starts a site in dir. Pages of earlier runs are overwritten.
+func NewSite(dir string, users *slack.UserDirectory, opts Options) (*Site, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "assets"), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create site folder: %w", err)
+	}
+	search, err := os.Create(filepath.Join(dir, "assets", "search-index.js.tmp"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create search index: %w", err)
+	}
+	s := &Site{
+		dir:          dir,
+		userMap:      UserNames(users, opts),
+		channelNames: opts.ChannelNames,
+		search:       search,
+		searchW:      bufio.NewWriter(search),
+	}
+	// A script instead of JSON, so the index also loads from file:// URLs
+	fmt.Fprint(s.searchW, "window.SEARCH_INDEX = [\n")
+	return s, nil
+}
+
+// siteIndexEntry is a channel on the index page
+type siteIndexEntry struct {
+	Label    string
+	Slug     string
+	Purpose  string
+	Messages int
+	First    string
+	Last     string
+}
+
+// siteMonth is a month on a channel page
+type siteMonth struct {
+	Name     string
+	Messages int
+}
+
+// siteDay groups the messages of a month page by local day
+type siteDay struct {
+	Date     string
+	Messages []siteMessage
+}
+
+// siteMessage is a message or reply as shown on a month page
+type siteMessage struct {
+	Anchor      string
+	User        string
+	Initials    string
+	Time        string
+	DateTime    string
+	Body        template.HTML
+	Attachments []string
+	Files       []siteFile
+	Reactions   []siteReaction
+	Edited      bool
+	Deleted     bool
+	Replies     []siteMessage
+	Warning     string
+}
+
+type siteFile struct {
+	Name  string
+	Href  string
+	Image bool
+}
+
+type siteReaction struct {
+	Name  string
+	Count int
+	Users string
+}
+
+// siteSearchEntry is a message in the search index
+type siteSearchEntry struct {
+	Channel string `json:"c"`
+	Page    string `json:"p"` // Relative to the site root, including the anchor
+	User    string `json:"u"`
+	Date    string `json:"d"`
+	Text    string `json:"t"`
+}
+
+// sitePage is the data of every page template; only the fields a page
+// uses are set
+type sitePage struct {
+	Title     string
+	Root      string // Relative path from the page to the site root
+	Generated string
+
+	Channels []siteIndexEntry
+
+	Channel siteIndexEntry
+	Topic   string
+	Months  []siteMonth
+
+	Month string
+	Prev  string
+	Next  string
+	Days  []siteDay
+}
+
+// AddChannel writes the pages of a channel one month at a time and adds
+// its messages to the search index
+func (s *Site) AddChannel(ctx context.Context, ch SiteChannel) error {
+	entry := siteIndexEntry{
+		Label:   siteChannelLabel(ch.Info),
+		Slug:    sanitizeFilename(ch.Info.Name),
+		Purpose: ch.Info.Purpose,
+	}
+	if entry.Slug == "" {
+		entry.Slug = ch.Info.ID
+	}
+	channelDir := filepath.Join(s.dir, "channels", entry.Slug)
+	if err := os.MkdirAll(channelDir, 0755); err != nil {
+		return fmt.Errorf("failed to create channel folder: %w", err)
+	}
+
+	var months []siteMonth
+	for i, month := range ch.Months {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msgs, err := ch.Load(month)
+		if err != nil {
+			return err
+		}
+		page := sitePage{
+			Title:   entry.Label + " · " + month,
+			Root:    "../../",
+			Channel: entry,
+			Month:   month,
+		}
+		if i > 0 {
+			page.Prev = ch.Months[i-1]
+		}
+		if i < len(ch.Months)-1 {
+			page.Next = ch.Months[i+1]
+		}
+		if page.Days, err = s.monthDays(ch, entry, month, msgs); err != nil {
+			return err
+		}
+		if err := s.writePage(filepath.Join(channelDir, month+".html"), "month.html", page); err != nil {
+			return err
+		}
+
+		months = append(months, siteMonth{Name: month, Messages: len(msgs)})
+		entry.Messages += len(msgs)
+		if len(msgs) > 0 {
+			if entry.First == "" {
+				entry.First = siteDate(msgs[0].Timestamp)
+			}
+			entry.Last = siteDate(msgs[len(msgs)-1].Timestamp)
+		}
+	}
+
+	page := sitePage{
+		Title:   entry.Label,
+		Root:    "../../",
+		Channel: entry,
+		Topic:   ch.Info.Topic,
+		Months:  months,
+	}
+	if err := s.writePage(filepath.Join(channelDir, "index.html"), "channel.html", page); err != nil {
+		return err
+	}
+	s.channels = append(s.channels, entry)
+	return nil
+}
+
+// monthDays builds the messages of a month page, copying their attachments
+// into the site and adding them to the search index
+func (s *Site) monthDays(ch SiteChannel, entry siteIndexEntry, month string, msgs []slack.Message) ([]siteDay, error) {
+	var days []siteDay
+	pagePath := "channels/" + entry.Slug + "/" + month + ".html"
+	for _, msg := range msgs {
+		m, err := s.siteMessage(ch, entry, msg.Message, msg.RevisionOf(msg.Timestamp))
+		if err != nil {
+			return nil, err
+		}
+		m.Warning = msg.ReplyWarning
+		if err := s.addSearchEntry(entry, pagePath, m, msg.Message); err != nil {
+			return nil, err
+		}
+		for _, reply := range msg.Replies {
+			r, err := s.siteMessage(ch, entry, reply, msg.RevisionOf(reply.Timestamp))
+			if err != nil {
+				return nil, err
+			}
+			if err := s.addSearchEntry(entry, pagePath, r, reply); err != nil {
+				return nil, err
+			}
+			m.Replies = append(m.Replies, r)
+		}
+
+		date := siteDate(msg.Timestamp)
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, siteDay{Date: date})
+		}
+		days[len(days)-1].Messages = append(days[len(days)-1].Messages, m)
+	}
+	return days, nil
+}
+
+func (s *Site) siteMessage(ch SiteChannel, entry siteIndexEntry, msg slackgo.Message, rev slack.Revision) (siteMessage, error) {
+	userName := getUserName(msg, s.userMap)
+	m := siteMessage{
+		Anchor:   siteAnchor(msg.Timestamp),
+		User:     userName,
+		Initials: initials(userName),
+		Edited:   msg.Edited != nil || len(rev.Previous) > 0,
+		Deleted:  rev.Deleted,
+	}
+	if t, err := slack.ParseTimestamp(msg.Timestamp); err == nil {
+		m.Time = t.Format("15:04")
+		m.DateTime = t.Format(messageTimeLayout)
+	}
+
+	// Block Kit layouts carry the full content when present, as in Markdown
+	blocks := ""
+	if len(msg.Blocks.BlockSet) > 0 {
+		blocks = renderBlocks(msg.Blocks.BlockSet, msg.Text, s.userMap, s.channelNames)
+	}
+	if blocks != "" || msg.Text != "" {
+		if blocks != "" {
+			m.Body = markdownHTML(blocks)
+		} else {
+			m.Body = slackHTML(msg.Text, s.userMap)
+		}
+		for _, a := range msg.Attachments {
+			if text := plainAttachment(a, s.userMap); text != "" {
+				m.Attachments = append(m.Attachments, text)
+			}
+		}
+	} else if text := plainText(msg, s.userMap, s.channelNames); text != "" {
+		// Bot messages without text carry their content in attachments
+		m.Body = template.HTML(`<div class="plain">` + template.HTMLEscapeString(text) + `</div>`)
+	}
+
+	for _, f := range msg.Files {
+		file := siteFile{Name: f.Name, Href: f.URLPrivate}
+		if local, ok := localAttachment(f, ch.Info.Name, ch.TargetFolder); ok {
+			// Copy the download into the site so it works without the export folder
+			rel := filepath.ToSlash(filepath.Join("attachments", entry.Slug, filepath.Base(local)))
+			if err := copyFile(filepath.Join(ch.TargetFolder, local), filepath.Join(s.dir, filepath.FromSlash(rel))); err != nil {
+				return siteMessage{}, err
+			}
+			file.Href = "../../" + rel
+			file.Image = isImage(f.Mimetype)
+		}
+		m.Files = append(m.Files, file)
+	}
+
+	for _, r := range msg.Reactions {
+		names := make([]string, 0, len(r.Users))
+		for _, id := range r.Users {
+			names = append(names, getUserName(slackgo.Message{Msg: slackgo.Msg{User: id}}, s.userMap))
+		}
+		m.Reactions = append(m.Reactions, siteReaction{Name: r.Name, Count: r.Count, Users: strings.Join(names, ", ")})
+	}
+	return m, nil
+}
+
+func (s *Site) addSearchEntry(entry siteIndexEntry, pagePath string, m siteMessage, msg slackgo.Message) error {
+	text := plainText(msg, s.userMap, s.channelNames)
+	if runes := []rune(text); len(runes) > siteSearchTextLimit {
+		text = string(runes[:siteSearchTextLimit])
+	}
+	data, err := json.Marshal(siteSearchEntry{
+		Channel: entry.Label,
+		Page:    pagePath + "#" + m.Anchor,
+		User:    m.User,
+		Date:    m.DateTime,
+		Text:    text,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode search entry: %w", err)
+	}
+	if s.entries > 0 {
+		fmt.Fprint(s.searchW, ",\n")
+	}
+	s.entries++
+	_, err = s.searchW.Write(data)
+	return err
+}
+
+// Close writes the index and search pages and the assets, and finishes the
+// search index
+func (s *Site) Close() error {
+	fmt.Fprint(s.searchW, "\n];\n")
+	if err := s.searchW.Flush(); err != nil {
+		s.Abort()
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	if err := s.search.Close(); err != nil {
+		os.Remove(s.search.Name())
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	if err := os.Rename(s.search.Name(), strings.TrimSuffix(s.search.Name(), ".tmp")); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+
+	for _, name := range []string{"style.css", "app.js"} {
+		data, err := siteAssets.ReadFile("siteassets/" + name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(s.dir, "assets", name), data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	sort.Slice(s.channels, func(i, j int) bool {
+		return s.channels[i].Label < s.channels[j].Label
+	})
+	index := sitePage{
+		Title:     "Slack Archive",
+		Channels:  s.channels,
+		Generated: time.Now().Format(messageTimeLayout),
+	}
+	if err := s.writePage(filepath.Join(s.dir, "index.html"), "index.html", index); err != nil {
+		return err
+	}
+	return s.writePage(filepath.Join(s.dir, "search.html"), "search.html", sitePage{Title: "Search"})
+}
+
+// Abort stops writing the search index; pages already written are kept
+func (s *Site) Abort() {
+	s.search.Close()
+	os.Remove(s.search.Name())
+}
+
+func (s *Site) writePage(path, name string, page sitePage) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create page: %w", err)
+	}
+	w := bufio.NewWriter(file)
+	err = siteTemplates.ExecuteTemplate(w, name, page)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// siteChannelLabel names a conversation as Slack does: "#general" for
+// channels, the participant names for DMs
+func siteChannelLabel(ch slack.CachedChannel) string {
+	if ch.IsIM || ch.IsMpIM {
+		return ch.Name
+	}
+	return "#" + ch.Name
+}
+
+// siteAnchor returns the element ID of a message, e.g. "m1700000000-000100"
+func siteAnchor(ts string) string {
+	return "m" + strings.ReplaceAll(ts, ".", "-")
+}
+
+func siteDate(ts string) string {
+	t, err := slack.ParseTimestamp(ts)
+	if err != nil {
+		return ""
+	}
+	return t.Format("2006-01-02 (Mon)")
+}
+
+// initials returns up to two letters shown as the avatar of a message author
+func initials(name string) string {
+	var letters []rune
+	for _, word := range strings.Fields(name) {
+		r := []rune(word)[0]
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			letters = append(letters, unicode.ToUpper(r))
+		}
+		if len(letters) == 2 {
+			break
+		}
+	}
+	return string(letters)
+}
+
+// copyFile copies a downloaded attachment unless an equally sized copy exists
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if existing, err := os.Stat(dst); err == nil && existing.Size() == info.Size() {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create attachments folder: %w", err)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to read attachment: %w", err)
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to copy attachment: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("failed to copy attachment: %w", err)
+	}
+	return out.Close()
+}
+
+var (
+	siteTokenRe  = regexp.MustCompile(`<([^<>\n]+)>`)
+	siteCodeRe   = regexp.MustCompile("`([^`\n]+)`")
+	siteBoldRe   = regexp.MustCompile(`(^|[\s(])\*([^*\n]+)\*`)
+	siteItalicRe = regexp.MustCompile(`(^|[\s(])_([^_\n]+)_`)
+	siteStrikeRe = regexp.MustCompile(`(^|[\s(])~([^~\n]+)~`)
+
+	// The Markdown written by renderBlocks
+	mdLinkRe   = regexp.MustCompile(`!?\[([^\]\n]*)\]\(([^()\s]+)\)`)
+	mdBoldRe   = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+	mdStrikeRe = regexp.MustCompile(`~~([^~\n]+)~~`)
+)
+
+// slackHTML converts Slack mrkdwn to HTML: code blocks, quotes, bold,
+// italic, strikethrough, inline code, links and mentions. Everything else
+// is escaped.
+func slackHTML(text string, userMap map[string]string) template.HTML {
+	var b strings.Builder
+	for i, part := range strings.Split(text, "```") {
+		if i%2 == 1 {
+			code := html.UnescapeString(strings.Trim(part, "\n"))
+			b.WriteString("<pre><code>" + template.HTMLEscapeString(code) + "</code></pre>")
+			continue
+		}
+		if part = strings.Trim(part, "\n"); part != "" {
+			b.WriteString(slackLinesHTML(part, userMap))
+		}
+	}
+	return template.HTML(b.String())
+}
+
+// markdownHTML converts the Markdown of renderBlocks to HTML: code blocks,
+// quotes, emphasis and links. List markers and indentation are kept as
+// text. Images are linked rather than embedded.
+func markdownHTML(md string) template.HTML {
+	var b strings.Builder
+	for i, part := range strings.Split(md, "```") {
+		if i%2 == 1 {
+			b.WriteString("<pre><code>" + template.HTMLEscapeString(strings.Trim(part, "\n")) + "</code></pre>")
+			continue
+		}
+		if part = strings.Trim(part, "\n"); part != "" {
+			b.WriteString(`<div class="plain">` + markdownLinesHTML(part) + "</div>")
+		}
+	}
+	return template.HTML(b.String())
+}
+
+// markdownLinesHTML converts lines of Markdown, grouping "> " lines into quotes
+func markdownLinesHTML(text string) string {
+	var b strings.Builder
+	inQuote := false
+	for i, line := range strings.Split(text, "\n") {
+		quote := line == ">" || strings.HasPrefix(line, "> ")
+		if quote {
+			line = strings.TrimPrefix(strings.TrimPrefix(line, ">"), " ")
+		}
+		switch {
+		case quote && !inQuote:
+			b.WriteString("<blockquote>")
+		case !quote && inQuote:
+			b.WriteString("</blockquote>")
+		case i > 0:
+			b.WriteString("\n")
+		}
+		inQuote = quote
+		b.WriteString(markdownInlineHTML(line))
+	}
+	if inQuote {
+		b.WriteString("</blockquote>")
+	}
+	return b.String()
+}
+
+func markdownInlineHTML(line string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range mdLinkRe.FindAllStringSubmatchIndex(line, -1) {
+		b.WriteString(markdownStyleHTML(line[last:m[0]]))
+		label, target := line[m[2]:m[3]], line[m[4]:m[5]]
+		if label == "" {
+			label = target
+		}
+		if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") || strings.HasPrefix(target, "mailto:") {
+			b.WriteString(`<a href="` + template.HTMLEscapeString(target) + `">` + markdownStyleHTML(label) + "</a>")
+		} else {
+			b.WriteString(markdownStyleHTML(label))
+		}
+		last = m[1]
+	}
+	b.WriteString(markdownStyleHTML(line[last:]))
+	return b.String()
+}
+
+// markdownStyleHTML escapes text and applies the inline styles of styleText
+func markdownStyleHTML(text string) string {
+	text = template.HTMLEscapeString(text)
+	text = siteCodeRe.ReplaceAllString(text, "<code>$1</code>")
+	text = mdBoldRe.ReplaceAllString(text, "<strong>$1</strong>")
+	text = siteItalicRe.ReplaceAllString(text, "$1<em>$2</em>")
+	return mdStrikeRe.ReplaceAllString(text, "<s>$1</s>")
+}
+
+// slackLinesHTML converts lines of mrkdwn, grouping "> " lines into quotes
+func slackLinesHTML(text string, userMap map[string]string) string {
+	var b strings.Builder
+	inQuote := false
+	for i, line := range strings.Split(text, "\n") {
+		quote := strings.HasPrefix(line, "&gt;")
+		if quote {
+			line = strings.TrimPrefix(strings.TrimPrefix(line, "&gt;"), " ")
+		}
+		switch {
+		case quote && !inQuote:
+			b.WriteString("<blockquote>")
+		case !quote && inQuote:
+			b.WriteString("</blockquote>")
+		case i > 0:
+			b.WriteString("<br>")
+		}
+		inQuote = quote
+		b.WriteString(slackInlineHTML(line, userMap))
+	}
+	if inQuote {
+		b.WriteString("</blockquote>")
+	}
+	return b.String()
+}
+
+func slackInlineHTML(line string, userMap map[string]string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range siteTokenRe.FindAllStringSubmatchIndex(line, -1) {
+		b.WriteString(formatHTML(line[last:m[0]]))
+		b.WriteString(slackTokenHTML(line[m[2]:m[3]], userMap))
+		last = m[1]
+	}
+	b.WriteString(formatHTML(line[last:]))
+	return b.String()
+}
+
+// formatHTML escapes text outside of Slack's <...> tokens and applies inline styles
+func formatHTML(text string) string {
+	text = template.HTMLEscapeString(html.UnescapeString(text))
+	text = siteCodeRe.ReplaceAllString(text, "<code>$1</code>")
+	text = siteBoldRe.ReplaceAllString(text, "$1<strong>$2</strong>")
+	text = siteItalicRe.ReplaceAllString(text, "$1<em>$2</em>")
+	return siteStrikeRe.ReplaceAllString(text, "$1<s>$2</s>")
+}
+
+// slackTokenHTML converts a <...> token: a mention, channel link, special
+// mention like @here, or a link with an optional label
+func slackTokenHTML(token string, userMap map[string]string) string {
+	target, label, _ := strings.Cut(token, "|")
+	mention := func(text string) string {
+		return `<span class="mention">` + template.HTMLEscapeString(text) + `</span>`
+	}
+	switch {
+	case strings.HasPrefix(target, "@"):
+		return mention(mentionName(target[1:], userMap))
+	case strings.HasPrefix(target, "#"):
+		if label == "" {
+			label = target[1:]
+		}
+		return mention("#" + label)
+	case strings.HasPrefix(target, "!"):
+		if label == "" {
+			name, _, _ := strings.Cut(target[1:], "^")
+			label = "@" + name
+		}
+		return mention(label)
+	}
+
+	if label == "" {
+		label = strings.TrimPrefix(target, "mailto:")
+	}
+	label = template.HTMLEscapeString(html.UnescapeString(label))
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") && !strings.HasPrefix(target, "mailto:") {
+		return label
+	}
+	href := template.HTMLEscapeString(html.UnescapeString(target))
+	return `<a href="` + href + `">` + label + `</a>`
+}
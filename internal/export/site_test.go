@@ -0,0 +1,88 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+func TestMarkdownHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{"styles", "**bold** _italic_ ~~gone~~ `code`", `<div class="plain"><strong>bold</strong> <em>italic</em> <s>gone</s> <code>code</code></div>`},
+		{"escaped", "a < b & c", `<div class="plain">a &lt; b &amp; c</div>`},
+		{"link", "see [the docs](https://example.com/a_b?x=1&y=2)", `<div class="plain">see <a href="https://example.com/a_b?x=1&amp;y=2">the docs</a></div>`},
+		{"image is linked", "![chart](https://example.com/chart.png)", `<div class="plain"><a href="https://example.com/chart.png">chart</a></div>`},
+		{"unsafe link", "[click](javascript:alert(1))", `<div class="plain">[click](javascript:alert(1))</div>`},
+		{"list", "1. **one**\n   - two", "<div class=\"plain\">1. <strong>one</strong>\n   - two</div>"},
+		{"quote", "before\n> quoted\n> more\nafter", "<div class=\"plain\">before<blockquote>quoted\nmore</blockquote>after</div>"},
+		{"code block", "run\n```\n<b>x</b>\n```", `<div class="plain">run</div><pre><code>&lt;b&gt;x&lt;/b&gt;</code></pre>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(markdownHTML(tt.md)); got != tt.want {
+				t.Errorf("markdownHTML(%q) =\n%s\nwant\n%s", tt.md, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSiteMessageBlocks checks that site pages render blocks before the
+// text, as channel files do, and show no remote avatars
+func TestSiteMessageBlocks(t *testing.T) {
+	users := slack.NewUserDirectory()
+	alice := slackgo.User{ID: "U00000001", RealName: "Alice Kim"}
+	alice.Profile.Image72 = "https://avatars.example.com/alice.png"
+	users.Add(alice, slackgo.User{ID: "U00000002", RealName: "Bob Lee"})
+	dir := t.TempDir()
+	s, err := NewSite(dir, users, Options{ChannelNames: testChannelNames})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Abort()
+	ch := SiteChannel{Info: slack.CachedChannel{ID: "C00000001", Name: "general", IsChannel: true}}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"formatted", "1. <strong>Tag the build</strong>"},
+		{"channel_mention", "See #general"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			msg := readTestMessage(t, filepath.Join("testdata", "blocks", tt.input+".json"))
+			m, err := s.siteMessage(ch, siteIndexEntry{Slug: "general"}, msg, slack.Revision{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(m.Body), tt.want) {
+				t.Errorf("body =\n%s\nwant it to contain %q", m.Body, tt.want)
+			}
+		})
+	}
+
+	// The author is shown by initials only
+	msg := readTestMessage(t, filepath.Join("testdata", "blocks", "formatted.json"))
+	msg.User = "U00000001"
+	ch.Months = []string{"2023-11"}
+	ch.Load = func(string) ([]slack.Message, error) { return []slack.Message{{Message: msg}}, nil }
+	if err := s.AddChannel(context.Background(), ch); err != nil {
+		t.Fatal(err)
+	}
+	page, err := os.ReadFile(filepath.Join(dir, "channels", "general", "2023-11.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(page), "avatars.example.com") || !strings.Contains(string(page), `<span class="avatar">AK</span>`) {
+		t.Errorf("month page links the avatar or lacks the initials:\n%s", page)
+	}
+}
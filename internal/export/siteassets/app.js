@@ -0,0 +1,99 @@
+// Slack archive site: permalinks into collapsed threads and the search page
+(function () {
+  'use strict';
+
+  // Open the thread holding a linked reply so the browser can show it
+  function reveal() {
+    var previous = document.querySelector('.message.target');
+    if (previous) previous.classList.remove('target');
+    if (!location.hash) return;
+    var el = document.getElementById(decodeURIComponent(location.hash.slice(1)));
+    if (!el) return;
+    for (var p = el.parentElement; p; p = p.parentElement) {
+      if (p.tagName === 'DETAILS') p.open = true;
+    }
+    el.classList.add('target');
+    el.scrollIntoView();
+  }
+  window.addEventListener('hashchange', reveal);
+  reveal();
+
+  var input = document.getElementById('query');
+  var index = window.SEARCH_INDEX;
+  if (!input || !index) return;
+
+  var results = document.getElementById('results');
+  var summary = document.getElementById('summary');
+  var limit = 200;
+
+  // Search keys are built once; every term has to match
+  for (var i = 0; i < index.length; i++) {
+    index[i].k = (index[i].u + ' ' + index[i].c + ' ' + index[i].t).toLowerCase();
+  }
+
+  function snippet(text, term) {
+    var at = text.toLowerCase().indexOf(term);
+    var start = Math.max(0, at - 60);
+    var s = (start > 0 ? '…' : '') + text.substr(start, 200) + (text.length > start + 200 ? '…' : '');
+    var frag = document.createDocumentFragment();
+    var lower = s.toLowerCase();
+    var pos = 0;
+    if (term) {
+      for (var hit = lower.indexOf(term); hit >= 0; hit = lower.indexOf(term, pos)) {
+        frag.appendChild(document.createTextNode(s.slice(pos, hit)));
+        var mark = document.createElement('mark');
+        mark.textContent = s.substr(hit, term.length);
+        frag.appendChild(mark);
+        pos = hit + term.length;
+      }
+    }
+    frag.appendChild(document.createTextNode(s.slice(pos)));
+    return frag;
+  }
+
+  function search() {
+    var terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    results.textContent = '';
+    if (!terms.length) {
+      summary.textContent = index.length + ' messages indexed';
+      return;
+    }
+    var found = 0;
+    for (var i = index.length - 1; i >= 0; i--) {
+      var e = index[i];
+      var match = true;
+      for (var j = 0; j < terms.length && match; j++) match = e.k.indexOf(terms[j]) >= 0;
+      if (!match) continue;
+      if (++found > limit) continue;
+
+      var li = document.createElement('li');
+      var a = document.createElement('a');
+      a.href = e.p;
+      a.textContent = e.u;
+      var where = document.createElement('div');
+      where.className = 'where';
+      where.textContent = e.c + ' · ' + e.d;
+      var text = document.createElement('div');
+      text.appendChild(snippet(e.t, terms[0]));
+      li.appendChild(a);
+      li.appendChild(where);
+      li.appendChild(text);
+      results.appendChild(li);
+    }
+    summary.textContent = found > limit
+      ? found + ' messages found, showing the newest ' + limit
+      : found + ' messages found';
+  }
+
+  var params = new URLSearchParams(location.search);
+  input.value = params.get('q') || '';
+  var timer;
+  input.addEventListener('input', function () {
+    clearTimeout(timer);
+    timer = setTimeout(function () {
+      history.replaceState(null, '', '?q=' + encodeURIComponent(input.value));
+      search();
+    }, 150);
+  });
+  search();
+})();
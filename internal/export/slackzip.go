@@ -0,0 +1,269 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+// slackZipDayLayout is the name of the per-day message files in a Slack export
+const slackZipDayLayout = "2006-01-02"
+
+// zipChannel is a conversation entry of channels.json, groups.json, mpims.json or dms.json
+type zipChannel struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name,omitempty"`
+	Created    int64     `json:"created"`
+	IsArchived bool      `json:"is_archived"`
+	Members    []string  `json:"members,omitempty"`
+	Topic      zipString `json:"topic"`
+	Purpose    zipString `json:"purpose"`
+}
+
+type zipString struct {
+	Value string `json:"value"`
+}
+
+// SlackZip writes channels in the official Slack workspace export layout
+// (channels.json, groups.json, mpims.json, dms.json, users.json and one
+// folder per conversation with a JSON file per day), so the archive can be
+// read by other Slack export viewers and migration tools.
+type SlackZip struct {
+	path    string
+	file    *os.File
+	zw      *zip.Writer
+	lists   map[string][]zipChannel // List file name → conversations
+	folders map[string]bool
+}
+
+// NewSlackZip starts a Slack export archive at the given path. The archive is
+// written to a temporary file and only appears at path after Close succeeds.
+func NewSlackZip(zipPath string) (*SlackZip, error) {
+	file, err := os.Create(zipPath + ".tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	return &SlackZip{
+		path:    zipPath,
+		file:    file,
+		zw:      zip.NewWriter(file),
+		lists:   make(map[string][]zipChannel),
+		folders: make(map[string]bool),
+	}, nil
+}
+
+// AddChannel writes a channel's messages, replies included, as per-day
+// files. months are the stored months (YYYY-MM), oldest first, and load
+// returns the messages of one month, so only a month is held at a time.
+// It returns the number of top-level messages written.
+func (z *SlackZip) AddChannel(ch slack.CachedChannel, months []string, load func(month string) ([]slack.Message, error)) (int, error) {
+	// Direct messages are stored under their ID, everything else under its name
+	folder := ch.Name
+	if ch.IsIM || folder == "" {
+		folder = ch.ID
+	}
+	if z.folders[folder] {
+		return 0, fmt.Errorf("duplicate conversation folder %q in archive", folder)
+	}
+	z.folders[folder] = true
+
+	listFile := "channels.json"
+	switch {
+	case ch.IsIM:
+		listFile = "dms.json"
+	case ch.IsMpIM:
+		listFile = "mpims.json"
+	case ch.IsPrivate || ch.IsGroup:
+		listFile = "groups.json"
+	}
+	entry := zipChannel{
+		ID:         ch.ID,
+		Created:    ch.Created,
+		IsArchived: ch.IsArchived,
+		Topic:      zipString{Value: ch.Topic},
+		Purpose:    zipString{Value: ch.Purpose},
+	}
+	if !ch.IsIM {
+		entry.Name = ch.Name
+	}
+
+	// DMs and group DMs list their participants. Without a cached member
+	// list they are the other participant and everyone who wrote.
+	members := newMemberSet(ch.Members)
+	if len(ch.Members) == 0 {
+		members.add(ch.User)
+	}
+
+	// Slack exports list replies next to their parents, grouped by UTC day.
+	// Messages deleted in Slack are left out, as Slack itself would. A
+	// month's segment holds the threads started in it, so once it is read
+	// no later segment adds to its days.
+	days := make(map[string][]slackgo.Message)
+	count := 0
+	for _, month := range months {
+		msgs, err := load(month)
+		if err != nil {
+			return count, err
+		}
+		for _, msg := range msgs {
+			count++
+			for _, m := range append([]slackgo.Message{msg.Message}, msg.Replies...) {
+				if msg.RevisionOf(m.Timestamp).Deleted {
+					continue
+				}
+				msgTime, err := slack.ParseTimestamp(m.Timestamp)
+				if err != nil {
+					continue
+				}
+				if len(ch.Members) == 0 {
+					members.add(m.User)
+				}
+				day := msgTime.UTC().Format(slackZipDayLayout)
+				days[day] = append(days[day], m)
+			}
+		}
+		if err := z.writeDays(folder, days, month); err != nil {
+			return count, err
+		}
+	}
+	if err := z.writeDays(folder, days, ""); err != nil {
+		return count, err
+	}
+
+	if ch.IsIM || ch.IsMpIM {
+		entry.Members = members.list
+	}
+	z.lists[listFile] = append(z.lists[listFile], entry)
+	return count, nil
+}
+
+// writeDays writes and forgets the days up to the end of the given month,
+// or every day when month is empty
+func (z *SlackZip) writeDays(folder string, days map[string][]slackgo.Message, month string) error {
+	var dayNames []string
+	for day := range days {
+		if month == "" || day[:len(month)] <= month {
+			dayNames = append(dayNames, day)
+		}
+	}
+	sort.Strings(dayNames)
+
+	for _, day := range dayNames {
+		dayMsgs := days[day]
+		sort.Slice(dayMsgs, func(i, j int) bool {
+			return dayMsgs[i].Timestamp < dayMsgs[j].Timestamp
+		})
+		if err := z.writeJSON(path.Join(folder, day+".json"), dayMsgs); err != nil {
+			return err
+		}
+		delete(days, day)
+	}
+	return nil
+}
+
+// memberSet collects user IDs in the order they are first seen
+type memberSet struct {
+	list []string
+	seen map[string]bool
+}
+
+func newMemberSet(ids []string) *memberSet {
+	m := &memberSet{seen: make(map[string]bool)}
+	for _, id := range ids {
+		m.add(id)
+	}
+	return m
+}
+
+func (m *memberSet) add(id string) {
+	if id != "" && !m.seen[id] {
+		m.seen[id] = true
+		m.list = append(m.list, id)
+	}
+}
+
+// Close writes the conversation lists and users.json and finishes the archive
+func (z *SlackZip) Close(users *slack.UserDirectory) error {
+	err := z.writeLists(users)
+	if cerr := z.zw.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to finish archive: %w", cerr)
+	}
+	if cerr := z.file.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("failed to write archive: %w", cerr)
+	}
+	if err == nil {
+		if rerr := os.Rename(z.file.Name(), z.path); rerr != nil {
+			err = fmt.Errorf("failed to replace archive: %w", rerr)
+		}
+	}
+	if err != nil {
+		os.Remove(z.file.Name())
+	}
+	return err
+}
+
+// Abort discards an unfinished archive
+func (z *SlackZip) Abort() {
+	z.zw.Close()
+	z.file.Close()
+	os.Remove(z.file.Name())
+}
+
+// writeLists writes every conversation list and the user list
+func (z *SlackZip) writeLists(users *slack.UserDirectory) error {
+	// channels.json is always present; other tools use it to detect an export
+	if _, ok := z.lists["channels.json"]; !ok {
+		z.lists["channels.json"] = []zipChannel{}
+	}
+	for _, name := range []string{"channels.json", "groups.json", "mpims.json", "dms.json"} {
+		list, ok := z.lists[name]
+		if !ok {
+			continue
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Name+list[i].ID < list[j].Name+list[j].ID
+		})
+		if err := z.writeJSON(name, list); err != nil {
+			return err
+		}
+	}
+
+	// users.json holds the full profiles, as in a Slack export
+	list := make([]slackgo.User, 0, len(users.Users))
+	for _, u := range users.Users {
+		if u.Profile.RealName == "" {
+			u.Profile.RealName = u.RealName
+		}
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return z.writeJSON("users.json", list)
+}
+
+func (z *SlackZip) writeJSON(name string, v interface{}) error {
+	w, err := z.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+// TestSlackZipMonths checks that a reply written in a later month than its
+// thread lands in that month's day file, and that DMs list their members
+func TestSlackZipMonths(t *testing.T) {
+	msg := func(user, ts string) slackgo.Message {
+		m := slackgo.Message{}
+		m.User, m.Timestamp, m.Text = user, ts, "hi"
+		return m
+	}
+	parent := slack.Message{Message: msg("U00000001", "1706659200.000100")}   // 2024-01-31
+	parent.Replies = []slackgo.Message{msg("U00000002", "1706832000.000100")} // 2024-02-02
+	months := map[string][]slack.Message{
+		"2024-01": {parent},
+		"2024-02": {{Message: msg("U00000001", "1706832000.000200")}},
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "export.zip")
+	z, err := NewSlackZip(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := slack.CachedChannel{ID: "D00000001", Name: "dm-bob", IsIM: true, User: "U00000002"}
+	count, err := z.AddChannel(dm, []string{"2024-01", "2024-02"}, func(month string) ([]slack.Message, error) {
+		return months[month], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+	if err := z.Close(slack.NewUserDirectory()); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	read := func(name string, v interface{}) {
+		t.Helper()
+		f, err := zr.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := json.NewDecoder(f).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var jan, feb []slackgo.Message
+	read("D00000001/2024-01-31.json", &jan)
+	read("D00000001/2024-02-02.json", &feb)
+	if len(jan) != 1 || len(feb) != 2 {
+		t.Errorf("day files hold %d and %d messages, want 1 and 2", len(jan), len(feb))
+	}
+
+	var dms []zipChannel
+	read("dms.json", &dms)
+	if len(dms) != 1 || len(dms[0].Members) != 2 || dms[0].Members[0] != "U00000002" || dms[0].Members[1] != "U00000001" {
+		t.Errorf("dms.json = %+v, want members U00000002 and U00000001", dms)
+	}
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+var (
+	plainUserRe    = regexp.MustCompile(`<@([A-Z0-9]+)(?:\|[^>]*)?>`)
+	plainChannelRe = regexp.MustCompile(`<#([A-Z0-9]+)(?:\|([^>]*))?>`)
+	plainSpecialRe = regexp.MustCompile(`<!([a-z]+)(?:\^[^|>]*)?(?:\|([^>]*))?>`)
+	plainLinkRe    = regexp.MustCompile(`<((?:https?|mailto):[^|>]+)(?:\|([^>]*))?>`)
+)
+
+// plainSlackText turns Slack mrkdwn into plain text: mentions become
+// "@Name", channel links "#name" and labelled links "label (url)"
+func plainSlackText(text string, userMap map[string]string) string {
+	text = plainUserRe.ReplaceAllStringFunc(text, func(m string) string {
+		return mentionName(plainUserRe.FindStringSubmatch(m)[1], userMap)
+	})
+	text = plainChannelRe.ReplaceAllStringFunc(text, func(m string) string {
+		match := plainChannelRe.FindStringSubmatch(m)
+		if match[2] != "" {
+			return "#" + match[2]
+		}
+		return "#" + match[1]
+	})
+	text = plainSpecialRe.ReplaceAllStringFunc(text, func(m string) string {
+		// <!here>, <!subteam^S123|@team>, <!date^1700000000^{date}|Nov 14>
+		match := plainSpecialRe.FindStringSubmatch(m)
+		if match[2] != "" {
+			return match[2]
+		}
+		return "@" + match[1]
+	})
+	text = plainLinkRe.ReplaceAllStringFunc(text, func(m string) string {
+		match := plainLinkRe.FindStringSubmatch(m)
+		url := strings.TrimPrefix(match[1], "mailto:")
+		if match[2] == "" || match[2] == url {
+			return url
+		}
+		return match[2] + " (" + url + ")"
+	})
+	return html.UnescapeString(text)
+}
+
+// plainText returns the text of a message for the plain formats. Messages
+// without text, e.g. from bots, fall back to their blocks or attachments.
+func plainText(msg slackgo.Message, userMap map[string]string, channelNames map[string]string) string {
+	if text := plainSlackText(msg.Text, userMap); strings.TrimSpace(text) != "" {
+		return text
+	}
+	if len(msg.Blocks.BlockSet) > 0 {
+		if rendered := renderBlocks(msg.Blocks.BlockSet, msg.Text, userMap, channelNames); rendered != "" {
+			return rendered
+		}
+	}
+	var parts []string
+	for _, a := range msg.Attachments {
+		if text := plainAttachment(a, userMap); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// plainAttachment returns the fallback text of an attachment, or its
+// pretext, title and text when it has none
+func plainAttachment(a slackgo.Attachment, userMap map[string]string) string {
+	text := a.Fallback
+	if text == "" {
+		text = strings.TrimSpace(a.Pretext + "\n" + a.Title + "\n" + a.Text)
+	}
+	return plainSlackText(text, userMap)
+}
+
+// Text writes a channel as a plain-text transcript, one message per
+// paragraph with thread replies indented under their parent
+type Text struct{}
+
+func (Text) Format() string    { return "text" }
+func (Text) Extension() string { return ".txt" }
+
+func (Text) Export(ctx context.Context, w io.Writer, ch Channel, msgs MessageSource) error {
+	fmt.Fprintf(w, "#%s\n", ch.Name)
+	fmt.Fprintf(w, "Exported: %s\n\n", time.Now().Format("2006-01-02 15:04:05"))
+
+	return msgs(func(msg slack.Message) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		parent, replies, err := newRecords(ctx, ch, msg)
+		if err != nil {
+			return err
+		}
+		writeTextRecord(w, parent, "")
+		for _, reply := range replies {
+			writeTextRecord(w, reply, "    ")
+		}
+		if parent.Warning != "" {
+			fmt.Fprintf(w, "    (thread incomplete: %s)\n", parent.Warning)
+		}
+		_, err = fmt.Fprintln(w)
+		return err
+	})
+}
+
+// writeTextRecord writes a message as "[2006-01-02 15:04] Name: text",
+// continuation lines, files and reactions indented below it
+func writeTextRecord(w io.Writer, r record, indent string) {
+	timeStr := r.TS
+	if t, err := slack.ParseTimestamp(r.TS); err == nil {
+		timeStr = t.Format("2006-01-02 15:04")
+	}
+	prefix := indent
+	if indent != "" {
+		prefix += "↳ "
+	}
+
+	text := r.Text
+	if r.Edited {
+		text = strings.TrimSpace(text + " (edited)")
+	}
+	if r.Deleted {
+		text = strings.TrimSpace(text + " (deleted in Slack)")
+	}
+	lines := strings.Split(text, "\n")
+	fmt.Fprintf(w, "%s[%s] %s: %s\n", prefix, timeStr, r.UserName, lines[0])
+
+	body := indent + "  "
+	for _, line := range lines[1:] {
+		fmt.Fprintf(w, "%s%s\n", body, line)
+	}
+	for _, f := range r.Files {
+		link := f.URL
+		if f.Path != "" {
+			link = f.Path
+		}
+		fmt.Fprintf(w, "%s[file] %s (%s)\n", body, f.Name, link)
+	}
+	if len(r.Reactions) > 0 {
+		parts := make([]string, 0, len(r.Reactions))
+		for _, reaction := range r.Reactions {
+			parts = append(parts, fmt.Sprintf(":%s: %d", reaction.Name, reaction.Count))
+		}
+		fmt.Fprintf(w, "%s%s\n", body, strings.Join(parts, " · "))
+	}
+}
@@ -0,0 +1,196 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+// Archive is an official Slack workspace export (ZIP) opened for reading.
+// Layout: channels.json, groups.json, dms.json, mpims.json, users.json and
+// one folder per conversation with a JSON file per day (YYYY-MM-DD.json).
+type Archive struct {
+	zr       *zip.ReadCloser
+	Channels []slack.CachedChannel
+	Users    []slackgo.User    // Full profiles from users.json
+	folders  map[string]string // Channel ID → folder name inside the archive
+}
+
+// OpenZip opens a Slack export archive and reads its channel and user lists
+func OpenZip(zipPath string) (*Archive, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	a := &Archive{
+		zr:      zr,
+		folders: make(map[string]string),
+	}
+	if err := a.readChannels(); err != nil {
+		zr.Close()
+		return nil, err
+	}
+	if err := a.readUsers(); err != nil {
+		zr.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// Close closes the underlying archive
+func (a *Archive) Close() error {
+	return a.zr.Close()
+}
+
+// readChannels reads every conversation list present in the archive.
+// Only channels.json is guaranteed; the others depend on the export type.
+func (a *Archive) readChannels() error {
+	lists := []struct {
+		file   string
+		toType func(*slack.CachedChannel)
+	}{
+		{"channels.json", func(c *slack.CachedChannel) { c.IsChannel = true }},
+		{"groups.json", func(c *slack.CachedChannel) { c.IsGroup = true; c.IsPrivate = true }},
+		{"mpims.json", func(c *slack.CachedChannel) { c.IsMpIM = true; c.IsPrivate = true }},
+		{"dms.json", func(c *slack.CachedChannel) { c.IsIM = true; c.IsPrivate = true }},
+	}
+
+	found := false
+	for _, list := range lists {
+		var channels []slackgo.Channel
+		ok, err := a.readJSON(list.file, &channels)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		found = true
+
+		for _, ch := range channels {
+			cc := slack.CachedChannel{
+				ID:         ch.ID,
+				Name:       ch.Name,
+				IsArchived: ch.IsArchived,
+				IsMember:   true,
+				NumMembers: len(ch.Members),
+				Members:    ch.Members,
+				Topic:      ch.Topic.Value,
+				Purpose:    ch.Purpose.Value,
+				Created:    int64(ch.Created),
+			}
+			list.toType(&cc)
+			a.Channels = append(a.Channels, cc)
+
+			// Direct messages are stored under their ID, everything else under its name
+			folder := ch.Name
+			if cc.IsIM || folder == "" {
+				folder = ch.ID
+			}
+			a.folders[ch.ID] = folder
+		}
+	}
+	if !found {
+		return fmt.Errorf("not a Slack export: no channels.json, groups.json, mpims.json or dms.json found")
+	}
+
+	sort.Slice(a.Channels, func(i, j int) bool {
+		return a.Channels[i].Name < a.Channels[j].Name
+	})
+	return nil
+}
+
+// readUsers reads the user profiles from users.json
+func (a *Archive) readUsers() error {
+	_, err := a.readJSON("users.json", &a.Users)
+	return err
+}
+
+// Messages returns a channel's messages with thread replies attached to
+// their parents, sorted from oldest to newest
+func (a *Archive) Messages(channelID string) ([]slack.Message, error) {
+	folder, ok := a.folders[channelID]
+	if !ok {
+		return nil, fmt.Errorf("channel %s not found in archive", channelID)
+	}
+
+	var all []slackgo.Message
+	for _, f := range a.zr.File {
+		if path.Dir(f.Name) != folder || path.Ext(f.Name) != ".json" {
+			continue
+		}
+		var day []slackgo.Message
+		if err := decodeFile(f, &day); err != nil {
+			return nil, err
+		}
+		all = append(all, day...)
+	}
+
+	return groupThreads(all), nil
+}
+
+// groupThreads attaches replies (thread_ts != ts) to their parent messages.
+// Replies whose parent is not in the export are kept as top-level messages.
+func groupThreads(all []slackgo.Message) []slack.Message {
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Timestamp < all[j].Timestamp
+	})
+
+	parents := make(map[string]int)
+	var msgs []slack.Message
+	for _, m := range all {
+		if m.ThreadTimestamp == "" || m.ThreadTimestamp == m.Timestamp {
+			parents[m.Timestamp] = len(msgs)
+			msgs = append(msgs, slack.Message{Message: m})
+		}
+	}
+	for _, m := range all {
+		if m.ThreadTimestamp == "" || m.ThreadTimestamp == m.Timestamp {
+			continue
+		}
+		if idx, ok := parents[m.ThreadTimestamp]; ok {
+			msgs[idx].Replies = append(msgs[idx].Replies, m)
+		} else {
+			msgs = append(msgs, slack.Message{Message: m})
+		}
+	}
+
+	sort.Slice(msgs, func(i, j int) bool {
+		return msgs[i].Timestamp < msgs[j].Timestamp
+	})
+	return msgs
+}
+
+// readJSON decodes a file at the root of the archive. It reports false if the file does not exist.
+func (a *Archive) readJSON(name string, v interface{}) (bool, error) {
+	for _, f := range a.zr.File {
+		if f.Name == name {
+			return true, decodeFile(f, v)
+		}
+	}
+	return false, nil
+}
+
+func decodeFile(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", f.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package importer
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	slackgo "github.com/slack-go/slack"
+)
+
+// writeZip writes an archive with the given files and returns its path
+func writeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "export.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// sampleExport is a workspace export with every kind of conversation
+var sampleExport = map[string]string{
+	"channels.json": `[{"id": "C2", "name": "random", "members": ["U1"]},
+		{"id": "C1", "name": "general", "is_archived": true, "members": ["U1", "U2"],
+		 "topic": {"value": "News"}, "purpose": {"value": "Company-wide"}, "created": 1700000000}]`,
+	"groups.json": `[{"id": "G1", "name": "secret", "members": ["U1"]}]`,
+	"mpims.json":  `[{"id": "G2", "name": "mpdm-alice--bob--carol-1", "members": ["U1", "U2", "U3"]}]`,
+	"dms.json":    `[{"id": "D1", "members": ["U1", "U2"]}]`,
+	"users.json":  `[{"id": "U1", "name": "alice", "real_name": "Alice Kim"}, {"id": "U2", "name": "bob"}]`,
+
+	"general/2023-11-14.json": `[
+		{"type": "message", "user": "U1", "text": "hello", "ts": "1700000000.000100"},
+		{"type": "message", "user": "U2", "text": "thread", "ts": "1700000060.000100", "thread_ts": "1700000060.000100", "reply_count": 2}]`,
+	"general/2023-11-15.json": `[
+		{"type": "message", "user": "U1", "text": "reply 1", "ts": "1700050000.000100", "thread_ts": "1700000060.000100"},
+		{"type": "message", "user": "U2", "text": "orphan", "ts": "1700050060.000100", "thread_ts": "1690000000.000100"},
+		{"type": "message", "user": "U2", "text": "next day", "ts": "1700050030.000100"}]`,
+	"general/2023-11-16.json": `[
+		{"type": "message", "user": "U2", "text": "reply 2", "ts": "1700100000.000100", "thread_ts": "1700000060.000100"}]`,
+	"general/notes.txt":      "not a day file",
+	"D1/2023-11-14.json":     `[{"type": "message", "user": "U2", "text": "hi", "ts": "1700000000.000200"}]`,
+	"secret/2023-11-14.json": `[]`,
+}
+
+func TestOpenZip(t *testing.T) {
+	a, err := OpenZip(writeZip(t, sampleExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	if len(a.Users) != 2 || a.Users[0].RealName != "Alice Kim" {
+		t.Errorf("users = %+v, want alice and bob", a.Users)
+	}
+
+	tests := []struct {
+		id        string
+		name      string
+		isChannel bool
+		isGroup   bool
+		isMpIM    bool
+		isIM      bool
+		private   bool
+		members   int
+	}{
+		{"D1", "", false, false, false, true, true, 2},
+		{"C1", "general", true, false, false, false, false, 2},
+		{"G2", "mpdm-alice--bob--carol-1", false, false, true, false, true, 3},
+		{"C2", "random", true, false, false, false, false, 1},
+		{"G1", "secret", false, true, false, false, true, 1},
+	}
+	if len(a.Channels) != len(tests) {
+		t.Fatalf("got %d channels, want %d", len(a.Channels), len(tests))
+	}
+	for i, tt := range tests {
+		ch := a.Channels[i]
+		if ch.ID != tt.id || ch.Name != tt.name {
+			t.Errorf("channel %d = %s %q, want %s %q (sorted by name)", i, ch.ID, ch.Name, tt.id, tt.name)
+			continue
+		}
+		if ch.IsChannel != tt.isChannel || ch.IsGroup != tt.isGroup || ch.IsMpIM != tt.isMpIM || ch.IsIM != tt.isIM || ch.IsPrivate != tt.private {
+			t.Errorf("%s: type = %+v", tt.id, ch)
+		}
+		if len(ch.Members) != tt.members || ch.NumMembers != tt.members || !ch.IsMember {
+			t.Errorf("%s: members = %v (%d), want %d", tt.id, ch.Members, ch.NumMembers, tt.members)
+		}
+	}
+	general := a.Channels[1]
+	if !general.IsArchived || general.Topic != "News" || general.Purpose != "Company-wide" || general.Created != 1700000000 {
+		t.Errorf("general details = %+v", general)
+	}
+}
+
+func TestOpenZipErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"no conversation lists", map[string]string{"users.json": "[]"}, "not a Slack export"},
+		{"broken channel list", map[string]string{"channels.json": "{"}, "failed to parse channels.json"},
+		{"broken user list", map[string]string{"dms.json": "[]", "users.json": "nope"}, "failed to parse users.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := OpenZip(writeZip(t, tt.files))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := OpenZip(filepath.Join(t.TempDir(), "missing.zip")); err == nil || !strings.Contains(err.Error(), "failed to open archive") {
+		t.Errorf("missing archive: err = %v", err)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	a, err := OpenZip(writeZip(t, sampleExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	tests := []struct {
+		id      string
+		want    []string // Top-level texts, oldest first
+		replies map[string][]string
+	}{
+		{"C1", []string{"hello", "thread", "next day", "orphan"}, map[string][]string{"thread": {"reply 1", "reply 2"}}},
+		{"D1", []string{"hi"}, nil}, // Stored under its ID
+		{"G1", nil, nil},
+		{"C2", nil, nil}, // No folder in the archive
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			msgs, err := a.Messages(tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range msgs {
+				got = append(got, m.Text)
+				var replies []string
+				for _, r := range m.Replies {
+					replies = append(replies, r.Text)
+				}
+				if strings.Join(replies, ",") != strings.Join(tt.replies[m.Text], ",") {
+					t.Errorf("replies of %q = %q, want %q", m.Text, replies, tt.replies[m.Text])
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("messages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := a.Messages("C9"); err == nil {
+		t.Error("unknown channel returned no error")
+	}
+}
+
+func TestGroupThreads(t *testing.T) {
+	msg := func(ts, threadTS, text string) slackgo.Message {
+		m := slackgo.Message{}
+		m.Timestamp, m.ThreadTimestamp, m.Text = ts, threadTS, text
+		return m
+	}
+	tests := []struct {
+		name string
+		in   []slackgo.Message
+		want string // Top-level messages with their replies in brackets
+	}{
+		{"empty", nil, ""},
+		{"unsorted", []slackgo.Message{msg("2", "", "b"), msg("1", "", "a")}, "a b"},
+		{"replies", []slackgo.Message{msg("3", "1", "r2"), msg("1", "1", "p"), msg("2", "1", "r1")}, "p[r1 r2]"},
+		{"orphan reply", []slackgo.Message{msg("2", "1", "r"), msg("3", "", "c")}, "r c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parts []string
+			for _, m := range groupThreads(tt.in) {
+				part := m.Text
+				if len(m.Replies) > 0 {
+					var replies []string
+					for _, r := range m.Replies {
+						replies = append(replies, r.Text)
+					}
+					part += "[" + strings.Join(replies, " ") + "]"
+				}
+				parts = append(parts, part)
+			}
+			if got := strings.Join(parts, " "); got != tt.want {
+				t.Errorf("groupThreads = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -53,6 +54,19 @@ type Contributor struct {
 	KeyContributions []string
 }
 
+// ReactionSignal summarizes the emoji reactions on one message. Reactions are
+// the main way people signal agreement and importance in Slack.
+type ReactionSignal struct {
+	Time      string         // "2006-01-02 15:04:05", as in the exported message header
+	Author    string
+	Excerpt   string         // Start of the message text
+	Total     int            // Sum of all reaction counts
+	Reactions map[string]int // Emoji name → count
+}
+
+// maxReactionSignals is the number of most-reacted messages passed to the LLM
+const maxReactionSignals = 20
+
 // ChannelAnalyzer performs LLM-based analysis on channel messages
 type ChannelAnalyzer struct {
 	client *Client
@@ -73,8 +87,9 @@ func (a *ChannelAnalyzer) GetClientProvider() string {
 	return string(a.client.Provider)
 }
 
-// AnalyzeChannel performs comprehensive analysis on channel content
-func (a *ChannelAnalyzer) AnalyzeChannel(channelName, content string) (*AnalysisResult, error) {
+// AnalyzeChannel performs comprehensive analysis on channel content.
+// reactions may be nil when the raw messages are not available.
+func (a *ChannelAnalyzer) AnalyzeChannel(ctx context.Context, channelName, content string, reactions []ReactionSignal) (*AnalysisResult, error) {
 	result := &AnalysisResult{
 		ChannelName: channelName,
 	}
@@ -82,7 +97,7 @@ func (a *ChannelAnalyzer) AnalyzeChannel(channelName, content string) (*Analysis
 	var totalUsage Usage
 
 	// Step 1: Extract Topics
-	topics, usage1, err := a.extractTopics(content)
+	topics, usage1, err := a.extractTopics(ctx, content, reactions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract topics: %w", err)
 	}
@@ -92,7 +107,7 @@ func (a *ChannelAnalyzer) AnalyzeChannel(channelName, content string) (*Analysis
 	totalUsage.TotalTokens += usage1.TotalTokens
 
 	// Step 2: Analyze Contributors
-	contributors, usage2, err := a.analyzeContributors(content)
+	contributors, usage2, err := a.analyzeContributors(ctx, content)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze contributors: %w", err)
 	}
@@ -102,7 +117,7 @@ func (a *ChannelAnalyzer) AnalyzeChannel(channelName, content string) (*Analysis
 	totalUsage.TotalTokens += usage2.TotalTokens
 
 	// Step 3: Generate Korean Summary
-	summary, usage3, err := a.generateKoreanSummary(channelName, content, topics)
+	summary, usage3, err := a.generateKoreanSummary(ctx, channelName, content, topics)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate summary: %w", err)
 	}
@@ -118,7 +133,7 @@ func (a *ChannelAnalyzer) AnalyzeChannel(channelName, content string) (*Analysis
 }
 
 // extractTopics identifies main discussion topics from the content
-func (a *ChannelAnalyzer) extractTopics(content string) ([]Topic, Usage, error) {
+func (a *ChannelAnalyzer) extractTopics(ctx context.Context, content string, reactions []ReactionSignal) ([]Topic, Usage, error) {
 	// Truncate content if too long (LLM context limit)
 	truncatedContent := truncateForLLM(content, 15000)
 
@@ -128,7 +143,7 @@ For each topic, provide:
 1. Topic name (short, descriptive)
 2. Brief description (1-2 sentences)
 3. Date range (e.g., "2023-10-01 ~ 2023-10-05") when this topic was discussed.
-4. Importance score (1-10, based on discussion length, participant count, urgency keywords)
+4. Importance score (1-10, based on discussion length, participant count, emoji reactions, urgency keywords)
 5. Key keywords (3-5 words)
 6. Sentiment breakdown (positive/negative/neutral message count estimate)
 
@@ -146,7 +161,7 @@ Format your response as JSON:
   ]
 }
 
-Conversation:
+` + formatReactionSignals(reactions) + `Conversation:
 ` + truncatedContent
 
 	messages := []ChatMessage{
@@ -155,7 +170,7 @@ Conversation:
 	}
 
 	// Increased max tokens to 16000 to support reasoning models like gemini-2.5-flash which use tokens for thinking
-	response, usage, err := a.client.Chat(messages, 0.2, 16000)
+	response, usage, err := a.client.Chat(ctx, messages, 0.2, 16000)
 	if err != nil {
 		return nil, Usage{}, err
 	}
@@ -166,7 +181,7 @@ Conversation:
 }
 
 // analyzeContributors identifies key contributors and their involvement
-func (a *ChannelAnalyzer) analyzeContributors(content string) ([]Contributor, Usage, error) {
+func (a *ChannelAnalyzer) analyzeContributors(ctx context.Context, content string) ([]Contributor, Usage, error) {
 	truncatedContent := truncateForLLM(content, 15000)
 
 	prompt := `Analyze the following Slack conversation and identify the key contributors.
@@ -197,7 +212,7 @@ Conversation:
 		{Role: "user", Content: prompt},
 	}
 
-	response, usage, err := a.client.Chat(messages, 0.2, 16000)
+	response, usage, err := a.client.Chat(ctx, messages, 0.2, 16000)
 	if err != nil {
 		return nil, Usage{}, err
 	}
@@ -207,7 +222,7 @@ Conversation:
 }
 
 // generateKoreanSummary creates a comprehensive Korean summary
-func (a *ChannelAnalyzer) generateKoreanSummary(channelName, content string, topics []Topic) (string, Usage, error) {
+func (a *ChannelAnalyzer) generateKoreanSummary(ctx context.Context, channelName, content string, topics []Topic) (string, Usage, error) {
 	truncatedContent := truncateForLLM(content, 12000)
 
 	// Build topic context
@@ -237,15 +252,18 @@ func (a *ChannelAnalyzer) generateKoreanSummary(channelName, content string, top
 		{Role: "user", Content: prompt},
 	}
 
-	return a.client.Chat(messages, 0.2, 16000)
+	return a.client.Chat(ctx, messages, 0.2, 16000)
 }
 
 // ProcessMultilingualContent handles translation of non-English messages
-func (a *ChannelAnalyzer) ProcessMultilingualContent(content string) (string, error) {
+func (a *ChannelAnalyzer) ProcessMultilingualContent(ctx context.Context, content string) (string, error) {
 	lines := strings.Split(content, "\n")
 	var result strings.Builder
 
 	for _, line := range lines {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
 		// Skip empty lines, headers, and metadata
 		trimmed := strings.TrimSpace(line)
 		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ">") || strings.HasPrefix(trimmed, "---") {
@@ -256,7 +274,7 @@ func (a *ChannelAnalyzer) ProcessMultilingualContent(content string) (string, er
 		// Check if line contains actual message content (not timestamps, names)
 		if !strings.Contains(line, " - ") && len(trimmed) > 50 {
 			// Detect language
-			lang, err := a.client.DetectLanguage(trimmed)
+			lang, err := a.client.DetectLanguage(ctx, trimmed)
 			if err != nil {
 				result.WriteString(line + "\n")
 				continue
@@ -264,7 +282,7 @@ func (a *ChannelAnalyzer) ProcessMultilingualContent(content string) (string, er
 
 			// If not English, add translation
 			if lang != "en" && lang != "" {
-				translation, err := a.client.TranslateToEnglish(trimmed, lang)
+				translation, err := a.client.TranslateToEnglish(ctx, trimmed, lang)
 				if err == nil && translation != "" {
 					result.WriteString(fmt.Sprintf("[EN] %s\n", translation))
 					result.WriteString(fmt.Sprintf("[%s] %s\n", strings.ToUpper(lang), trimmed))
@@ -281,6 +299,43 @@ func (a *ChannelAnalyzer) ProcessMultilingualContent(content string) (string, er
 
 // Helper functions
 
+// formatReactionSignals lists the most-reacted messages for the topic prompt
+func formatReactionSignals(reactions []ReactionSignal) string {
+	if len(reactions) == 0 {
+		return ""
+	}
+	sorted := make([]ReactionSignal, len(reactions))
+	copy(sorted, reactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Total > sorted[j].Total
+	})
+	if len(sorted) > maxReactionSignals {
+		sorted = sorted[:maxReactionSignals]
+	}
+
+	var b strings.Builder
+	b.WriteString("Most reacted messages (emoji reactions signal agreement and importance; weigh them in the importance score):\n")
+	for _, r := range sorted {
+		names := make([]string, 0, len(r.Reactions))
+		for name := range r.Reactions {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if r.Reactions[names[i]] != r.Reactions[names[j]] {
+				return r.Reactions[names[i]] > r.Reactions[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		emoji := make([]string, 0, len(names))
+		for _, name := range names {
+			emoji = append(emoji, fmt.Sprintf(":%s: %d", name, r.Reactions[name]))
+		}
+		b.WriteString(fmt.Sprintf("- [%s] %s: \"%s\" — %d reactions (%s)\n", r.Time, r.Author, r.Excerpt, r.Total, strings.Join(emoji, ", ")))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
 func truncateForLLM(content string, maxChars int) string {
 	if len(content) <= maxChars {
 		return content
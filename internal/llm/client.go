@@ -2,6 +2,7 @@ package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -90,7 +91,7 @@ type Usage struct {
 }
 
 // Chat sends a chat completion request (routes to appropriate provider)
-func (c *Client) Chat(messages []ChatMessage, temperature float64, maxTokens int) (string, Usage, error) {
+func (c *Client) Chat(ctx context.Context, messages []ChatMessage, temperature float64, maxTokens int) (string, Usage, error) {
 	if c.APIKey == "" {
 		return "", Usage{}, fmt.Errorf("LLM API key is not configured")
 	}
@@ -101,9 +102,9 @@ func (c *Client) Chat(messages []ChatMessage, temperature float64, maxTokens int
 
 	switch c.Provider {
 	case ProviderGemini:
-		content, usage, err = c.chatGemini(messages, temperature, maxTokens)
+		content, usage, err = c.chatGemini(ctx, messages, temperature, maxTokens)
 	default:
-		content, usage, err = c.chatOpenAI(messages, temperature, maxTokens)
+		content, usage, err = c.chatOpenAI(ctx, messages, temperature, maxTokens)
 	}
 
 	if err == nil {
@@ -138,7 +139,7 @@ type openAIChatResponse struct {
 	} `json:"error,omitempty"`
 }
 
-func (c *Client) chatOpenAI(messages []ChatMessage, temperature float64, maxTokens int) (string, Usage, error) {
+func (c *Client) chatOpenAI(ctx context.Context, messages []ChatMessage, temperature float64, maxTokens int) (string, Usage, error) {
 	reqBody := openAIChatRequest{
 		Model:       c.Model,
 		Messages:    messages,
@@ -151,7 +152,7 @@ func (c *Client) chatOpenAI(messages []ChatMessage, temperature float64, maxToke
 		return "", Usage{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", c.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", Usage{}, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -230,7 +231,7 @@ type geminiResponse struct {
 	} `json:"error,omitempty"`
 }
 
-func (c *Client) chatGemini(messages []ChatMessage, temperature float64, maxTokens int) (string, Usage, error) {
+func (c *Client) chatGemini(ctx context.Context, messages []ChatMessage, temperature float64, maxTokens int) (string, Usage, error) {
 	// Convert messages to Gemini format
 	var contents []geminiContent
 	var systemInstruction *geminiContent
@@ -275,7 +276,7 @@ func (c *Client) chatGemini(messages []ChatMessage, temperature float64, maxToke
 	// Gemini API URL format: /models/{model}:generateContent?key={apiKey}
 	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", c.BaseURL, c.Model, c.APIKey)
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", Usage{}, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -325,17 +326,17 @@ func (c *Client) chatGemini(messages []ChatMessage, temperature float64, maxToke
 }
 
 // SimpleChat is a convenience method for single-turn conversations
-func (c *Client) SimpleChat(systemPrompt, userPrompt string) (string, error) {
+func (c *Client) SimpleChat(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	messages := []ChatMessage{
 		{Role: "system", Content: systemPrompt},
 		{Role: "user", Content: userPrompt},
 	}
-	content, _, err := c.Chat(messages, 0.3, 4096)
+	content, _, err := c.Chat(ctx, messages, 0.3, 4096)
 	return content, err
 }
 
 // DetectLanguage detects the language of the given text
-func (c *Client) DetectLanguage(text string) (string, error) {
+func (c *Client) DetectLanguage(ctx context.Context, text string) (string, error) {
 	// Truncate if too long
 	if len(text) > 500 {
 		text = text[:500]
@@ -351,7 +352,7 @@ Text: ` + text
 		{Role: "user", Content: prompt},
 	}
 
-	result, _, err := c.Chat(messages, 0, 10)
+	result, _, err := c.Chat(ctx, messages, 0, 10)
 	if err != nil {
 		return "en", err // Default to English on error
 	}
@@ -360,23 +361,23 @@ Text: ` + text
 }
 
 // TranslateToEnglish translates non-English text to English
-func (c *Client) TranslateToEnglish(text, sourceLang string) (string, error) {
+func (c *Client) TranslateToEnglish(ctx context.Context, text, sourceLang string) (string, error) {
 	prompt := fmt.Sprintf(`Translate the following %s text to English.
 Provide ONLY the translation, no explanations.
 
 Text: %s`, getLanguageName(sourceLang), text)
 
-	return c.SimpleChat("You are a professional translator.", prompt)
+	return c.SimpleChat(ctx, "You are a professional translator.", prompt)
 }
 
 // TranslateToKorean translates text to Korean
-func (c *Client) TranslateToKorean(text string) (string, error) {
+func (c *Client) TranslateToKorean(ctx context.Context, text string) (string, error) {
 	prompt := `Translate the following text to Korean.
 Provide ONLY the translation, no explanations.
 
 Text: ` + text
 
-	return c.SimpleChat("You are a professional translator specializing in technical content.", prompt)
+	return c.SimpleChat(ctx, "You are a professional translator specializing in technical content.", prompt)
 }
 
 func getLanguageName(code string) string {
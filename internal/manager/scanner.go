@@ -1,22 +1,17 @@
 package manager
 
 import (
-	"bufio"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
-)
 
-var (
-	dateHeaderRegex = regexp.MustCompile(`## 📅 (\d{4}-\d{2}-\d{2})`)
-	timeHeaderRegex = regexp.MustCompile(`### .* - (\d{2}:\d{2}:\d{2})`)
+	"github.com/chanseok/slackExtract/internal/mdformat"
 )
 
-// ScanExportDir scans the export directory for existing channel files
+// ScanExportDir scans the export directory for existing channel files.
+// Files whose metadata cannot be read are listed in Warnings.
 func ScanExportDir(exportRoot string) (*ScanResult, error) {
 	result := &ScanResult{
 		Channels: make(map[string]ChannelMeta),
@@ -47,19 +42,20 @@ func ScanExportDir(exportRoot string) (*ScanResult, error) {
 		isArchived := strings.Contains(filepath.Dir(relPath), "archived")
 
 		// Parse last message time
-		lastMsgTime, msgCount, err := parseFileMetadata(path)
+		channelID, lastMsgTime, msgCount, err := parseFileMetadata(path)
 		if err != nil {
-			// Log error but continue?
-			fmt.Printf("Warning: failed to parse metadata for %s: %v\n", path, err)
+			// Reported by the caller, which owns the output
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to parse metadata for %s: %v", path, err))
 		}
 
 		meta := ChannelMeta{
 			ChannelName:     channelName,
+			ChannelID:       channelID,
 			FilePath:        relPath,
 			FileSize:        info.Size(),
 			LastUpdated:     info.ModTime(),
 			LastMessageTime: lastMsgTime,
-			MessageCount:    msgCount, // This is expensive to count exactly, maybe estimate or skip for now
+			MessageCount:    msgCount,
 			IsArchived:      isArchived,
 		}
 
@@ -70,84 +66,16 @@ func ScanExportDir(exportRoot string) (*ScanResult, error) {
 	return result, err
 }
 
-// parseFileMetadata reads the file to find the last message timestamp and estimate message count
-func parseFileMetadata(path string) (time.Time, int, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return time.Time{}, 0, err
-	}
-	defer f.Close()
-
-	info, err := f.Stat()
-	if err != nil {
-		return time.Time{}, 0, err
-	}
-
-	// 1. Estimate message count (rough count of "### " lines)
-	// For large files, scanning the whole file might be slow.
-	// Let's skip exact count for now or do a fast scan if file is small (< 1MB)
-	msgCount := 0
-	if info.Size() < 1024*1024 { // 1MB
-		scanner := bufio.NewScanner(f)
-		for scanner.Scan() {
-			if strings.HasPrefix(scanner.Text(), "### ") {
-				msgCount++
-			}
-		}
-		// Reset file pointer for timestamp parsing
-		f.Seek(0, 0)
-	}
-
-	// 2. Find last timestamp
-	// Read last 20KB
-	const tailSize = 20 * 1024
-	offset := int64(0)
-	if info.Size() > tailSize {
-		offset = info.Size() - tailSize
-	}
-	
-	_, err = f.Seek(offset, 0)
-	if err != nil {
-		return time.Time{}, msgCount, err
-	}
-
-	content, err := io.ReadAll(f)
+// parseFileMetadata reads the channel ID, last message timestamp and message
+// count from the front matter, or from the messages in files without one
+func parseFileMetadata(path string) (string, time.Time, int, error) {
+	fm, err := mdformat.ReadFrontMatterFile(path)
 	if err != nil {
-		return time.Time{}, msgCount, err
+		return "", time.Time{}, 0, err
 	}
-	
-	strContent := string(content)
-
-	// Find last date
-	dateMatches := dateHeaderRegex.FindAllStringSubmatch(strContent, -1)
-	var lastDateStr string
-	var lastDateIdx int
-	
-	if len(dateMatches) > 0 {
-		lastMatch := dateMatches[len(dateMatches)-1]
-		lastDateStr = lastMatch[1]
-		// Find index of this match in strContent to search for time after it
-		lastDateIdx = strings.LastIndex(strContent, lastMatch[0])
-	} else {
-		// If no date found in tail, maybe the file is small and we read it all?
-		// Or the date header is further up.
-		// Fallback: try to find date in the whole file if we haven't read it all?
-		// For now, return zero time if not found
-		return time.Time{}, msgCount, nil
+	if fm != nil {
+		return fm.ChannelID, fm.LastMessageTime(), fm.MessageCount, nil
 	}
-
-	// Find last time AFTER the date
-	timeContent := strContent[lastDateIdx:]
-	timeMatches := timeHeaderRegex.FindAllStringSubmatch(timeContent, -1)
-	
-	if len(timeMatches) > 0 {
-		lastTimeStr := timeMatches[len(timeMatches)-1][1]
-		fullTimeStr := fmt.Sprintf("%s %s", lastDateStr, lastTimeStr)
-		t, err := time.Parse("2006-01-02 15:04:05", fullTimeStr)
-		if err == nil {
-			return t, msgCount, nil
-		}
-	}
-
-	return time.Time{}, msgCount, nil
+	stats, err := mdformat.ParseFile(path)
+	return "", stats.Last, stats.Messages, err
 }
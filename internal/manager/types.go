@@ -10,7 +10,7 @@ type ChannelMeta struct {
 	ChannelID       string    // Might be empty if scanned from file system
 	FilePath        string    // Relative path from export root
 	FileSize        int64
-	MessageCount    int       // Estimated or parsed
+	MessageCount    int       // Parsed from content
 	LastUpdated     time.Time // File modification time
 	LastMessageTime time.Time // Parsed from content
 	IsArchived      bool      // True if file is in "archived" folder
@@ -19,4 +19,5 @@ type ChannelMeta struct {
 // ScanResult holds the result of scanning the export directory
 type ScanResult struct {
 	Channels map[string]ChannelMeta // Key: ChannelName
+	Warnings []string               // Files whose metadata could not be read
 }
@@ -0,0 +1,218 @@
+package mdformat
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// frontMatterDelimiter opens and closes the front matter block
+const frontMatterDelimiter = "---"
+
+// FrontMatter is the YAML block at the top of a channel file. It describes
+// the channel and the exported message range, so the file can be indexed
+// without reading its messages, by the export scan as well as by tools
+// like Obsidian or static site generators.
+type FrontMatter struct {
+	ChannelID       string
+	Name            string
+	Type            string // public_channel, private_channel, mpim or im; "" if unknown
+	Private         bool
+	Folder          string // Subfolder under the export root, "" for the root
+	Topic           string
+	Purpose         string
+	FirstMessageTS  string // Slack ts of the first top-level message
+	LastMessageTS   string // Slack ts of the last top-level message
+	MessageCount    int    // Number of top-level messages
+	ExportedAt      time.Time
+	Timezone        string // Zone of the dates and times in the file
+	Exporter        string // Tool that wrote the file
+	ExporterVersion string
+	FormatVersion   int
+	Tags            []string // e.g. "slack/public_channel", for Obsidian
+	Aliases         []string // Other names of the note, for Obsidian
+}
+
+// LastMessageTime returns the time of the last message, zero if unknown
+func (f FrontMatter) LastMessageTime() time.Time {
+	return tsTime(f.LastMessageTS)
+}
+
+// FirstMessageTime returns the time of the first message, zero if unknown
+func (f FrontMatter) FirstMessageTime() time.Time {
+	return tsTime(f.FirstMessageTS)
+}
+
+// Write writes the front matter block. Strings are written as
+// double-quoted scalars, so names and topics never need escaping rules of
+// their own.
+func (f FrontMatter) Write(w io.Writer) error {
+	var b strings.Builder
+	field := func(key, value string) {
+		fmt.Fprintf(&b, "%s: %s\n", key, value)
+	}
+
+	b.WriteString(frontMatterDelimiter + "\n")
+	field("channel_id", quote(f.ChannelID))
+	field("name", quote(f.Name))
+	if f.Type != "" {
+		field("type", f.Type)
+	}
+	field("private", strconv.FormatBool(f.Private))
+	field("folder", quote(f.Folder))
+	field("topic", quote(f.Topic))
+	field("purpose", quote(f.Purpose))
+	// Quoted: unquoted, YAML would read a ts as a float and lose digits
+	field("first_message_ts", quote(f.FirstMessageTS))
+	field("last_message_ts", quote(f.LastMessageTS))
+	field("message_count", strconv.Itoa(f.MessageCount))
+	field("exported_at", f.ExportedAt.Format(time.RFC3339))
+	field("timezone", quote(f.Timezone))
+	field("exporter", quote(f.Exporter))
+	field("exporter_version", quote(f.ExporterVersion))
+	field("format_version", strconv.Itoa(f.FormatVersion))
+	if len(f.Tags) > 0 {
+		field("tags", quoteList(f.Tags))
+	}
+	if len(f.Aliases) > 0 {
+		field("aliases", quoteList(f.Aliases))
+	}
+	b.WriteString(frontMatterDelimiter + "\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ReadFrontMatter returns the front matter at the start of a channel file,
+// or nil if it has none (layout version 2 and earlier). Only the front
+// matter is read.
+func ReadFrontMatter(r io.Reader) (*FrontMatter, error) {
+	return readFrontMatter(bufio.NewReader(r))
+}
+
+// ReadFrontMatterFile returns the front matter of the channel file at path
+func ReadFrontMatterFile(path string) (*FrontMatter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadFrontMatter(f)
+}
+
+func readFrontMatter(reader *bufio.Reader) (*FrontMatter, error) {
+	head, err := reader.Peek(len(frontMatterDelimiter) + 1)
+	if err != nil || strings.TrimRight(string(head), "\r\n") != frontMatterDelimiter {
+		return nil, nil
+	}
+	reader.ReadString('\n')
+
+	fm := &FrontMatter{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == frontMatterDelimiter {
+			return fm, nil
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fm.set(strings.TrimSpace(key), strings.TrimSpace(value))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unterminated front matter")
+		}
+	}
+}
+
+// set reads a field written by Write. Fields added by other tools are ignored.
+func (f *FrontMatter) set(key, value string) {
+	str := value
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal([]byte(value), &str); err != nil {
+			str = strings.Trim(value, `"`)
+		}
+	} else {
+		str = strings.Trim(value, `'`)
+	}
+
+	switch key {
+	case "channel_id":
+		f.ChannelID = str
+	case "name":
+		f.Name = str
+	case "type":
+		f.Type = str
+	case "private":
+		f.Private, _ = strconv.ParseBool(str)
+	case "folder":
+		f.Folder = str
+	case "topic":
+		f.Topic = str
+	case "purpose":
+		f.Purpose = str
+	case "first_message_ts":
+		f.FirstMessageTS = str
+	case "last_message_ts":
+		f.LastMessageTS = str
+	case "message_count":
+		f.MessageCount, _ = strconv.Atoi(str)
+	case "exported_at":
+		f.ExportedAt, _ = time.Parse(time.RFC3339, str)
+	case "timezone":
+		f.Timezone = str
+	case "exporter":
+		f.Exporter = str
+	case "exporter_version":
+		f.ExporterVersion = str
+	case "format_version":
+		f.FormatVersion, _ = strconv.Atoi(str)
+	case "tags":
+		json.Unmarshal([]byte(value), &f.Tags)
+	case "aliases":
+		json.Unmarshal([]byte(value), &f.Aliases)
+	}
+}
+
+// quote writes a string as a YAML double-quoted scalar. JSON strings are
+// valid YAML, escapes included.
+func quote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// quoteList writes strings as a YAML flow sequence of double-quoted scalars
+func quoteList(list []string) string {
+	data, _ := json.Marshal(list)
+	return string(data)
+}
+
+// tsTime converts a Slack ts such as "1700000000.000100"
+func tsTime(ts string) time.Time {
+	sec, frac, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	var usec int64
+	if frac != "" {
+		usec, _ = strconv.ParseInt((frac + "000000")[:6], 10, 64)
+	}
+	return time.Unix(s, usec*1000)
+}
+
+// LocalZone names the time zone the file's dates and times are written in,
+// e.g. "Asia/Seoul", or its UTC offset when the zone has no name
+func LocalZone() string {
+	if name := time.Local.String(); name != "" && name != "Local" {
+		return name
+	}
+	return time.Now().Format("-07:00")
+}
@@ -0,0 +1,193 @@
+// Package mdformat defines the layout of exported Markdown channel files. The
+// writer in internal/export and every reader of the files (the export
+// directory scan, slack-analyze) go through it, so they cannot drift apart.
+//
+// A file of the current layout looks like
+//
+//	---
+//	channel_id: "C0123456"
+//	name: "general"
+//	...
+//	format_version: 3
+//	---
+//
+//	# general
+//
+//	Exported: 2025-06-17 09:00:00
+//
+//	---
+//
+//	## 📅 2025-06-17
+//
+//	### Alice Kim - 17:14:01
+//
+//	Message text
+//
+//	> **Bob Lee** - 2025-06-17 17:20:00
+//	> Thread reply
+//
+//	---
+//
+// The front matter (see FrontMatter) describes the channel. Top-level
+// messages are grouped under a date header per local day and their
+// headers carry the time only. Thread replies keep the full date and time,
+// as they may be posted days after their parent.
+package mdformat
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the layout version written to new files:
+//
+//	1: no version marker and no date headers; message headers carry the
+//	   full date and time ("### Alice Kim - 2025-06-17 17:14:01")
+//	2: version marker comment, "## 📅 2025-06-17" date headers and
+//	   time-only message headers ("### Alice Kim - 17:14:01")
+//	3: YAML front matter, declaring the version in format_version
+const Version = 3
+
+// Time formats of headers, in local time
+const (
+	DateLayout     = "2006-01-02"
+	TimeLayout     = "15:04:05"
+	DateTimeLayout = DateLayout + " " + TimeLayout
+)
+
+const (
+	datePrefix    = "## 📅 "
+	messagePrefix = "### "
+)
+
+var (
+	versionRegex = regexp.MustCompile(`^<!-- slack-extract format: (\d+) -->$`)
+	headerRegex  = regexp.MustCompile(`^### (.+) - (\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}|\d{2}:\d{2}:\d{2})$`)
+)
+
+// DateHeader returns the header opening the messages of t's day
+func DateHeader(t time.Time) string {
+	return datePrefix + t.Format(DateLayout)
+}
+
+// MessageHeader returns the heading line of a top-level message
+func MessageHeader(userName string, t time.Time) string {
+	return messagePrefix + userName + " - " + t.Format(TimeLayout)
+}
+
+// Stats summarizes the messages of a channel file
+type Stats struct {
+	Version  int            // Layout version; 1 for files without a marker
+	Messages int            // Number of top-level messages
+	First    time.Time      // Time of the first message, zero if unknown
+	Last     time.Time      // Time of the last message, zero if unknown
+	PerDay   map[string]int // Top-level messages per day (DateLayout)
+
+	FrontMatter *FrontMatter // nil for files of layout version 2 and earlier
+}
+
+// Days returns the days with messages, oldest first
+func (s Stats) Days() []string {
+	days := make([]string, 0, len(s.PerDay))
+	for day := range s.PerDay {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	return days
+}
+
+// PeakDay returns the day with the most messages (the earliest of equally
+// busy days) and its message count, or "" if no message is dated
+func (s Stats) PeakDay() (string, int) {
+	peak, max := "", 0
+	for _, day := range s.Days() {
+		if s.PerDay[day] > max {
+			peak, max = day, s.PerDay[day]
+		}
+	}
+	return peak, max
+}
+
+// Parse reads a channel file of any layout version line by line, so memory
+// use does not depend on the size of the file. Lines inside code blocks are
+// message text and never taken for headers.
+func Parse(r io.Reader) (Stats, error) {
+	stats := Stats{Version: 1, PerDay: make(map[string]int)}
+	reader := bufio.NewReader(r)
+	fm, err := readFrontMatter(reader)
+	if err != nil {
+		return stats, err
+	}
+	if fm != nil {
+		stats.FrontMatter = fm
+		stats.Version = fm.FormatVersion
+	}
+	var day string
+	inCode := false
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return stats, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "```"):
+			inCode = !inCode
+		case inCode:
+		case strings.HasPrefix(line, "<!-- "):
+			// Layout version 2 declared its version in a comment
+			if m := versionRegex.FindStringSubmatch(line); m != nil {
+				stats.Version, _ = strconv.Atoi(m[1])
+			}
+		case strings.HasPrefix(line, datePrefix):
+			if t, err := time.ParseInLocation(DateLayout, strings.TrimSpace(line[len(datePrefix):]), time.Local); err == nil {
+				day = t.Format(DateLayout)
+			}
+		case strings.HasPrefix(line, messagePrefix):
+			m := headerRegex.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			stats.Messages++
+			value, layout := m[2], DateTimeLayout
+			if len(value) == len(TimeLayout) {
+				if day == "" {
+					break
+				}
+				value = day + " " + value
+			}
+			t, err := time.ParseInLocation(layout, value, time.Local)
+			if err != nil {
+				break
+			}
+			if stats.First.IsZero() {
+				stats.First = t
+			}
+			stats.Last = t
+			stats.PerDay[t.Format(DateLayout)]++
+		}
+
+		if err != nil {
+			return stats, nil
+		}
+	}
+}
+
+// ParseFile reads the channel file at path
+func ParseFile(path string) (Stats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
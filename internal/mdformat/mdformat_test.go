@@ -0,0 +1,185 @@
+package mdformat_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/export"
+	"github.com/chanseok/slackExtract/internal/mdformat"
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+func init() {
+	time.Local = time.UTC
+}
+
+func message(ts, user, text string, replies ...slackgo.Message) slack.Message {
+	msg := slack.Message{Message: slackgo.Message{Msg: slackgo.Msg{Timestamp: ts, User: user, Text: text}}}
+	msg.Replies = replies
+	return msg
+}
+
+func reply(ts, user, text string) slackgo.Message {
+	return slackgo.Message{Msg: slackgo.Msg{Timestamp: ts, User: user, Text: text}}
+}
+
+// TestRoundTrip writes a channel with the Markdown exporter and checks that
+// Parse reads back the same messages, days and format version
+func TestRoundTrip(t *testing.T) {
+	msgs := []slack.Message{
+		message("1700000000.000100", "U1", "first"),
+		// Replies are posted the next day and must not count as messages
+		message("1700000060.000100", "U2", "thread", reply("1700090000.000100", "U1", "next day")),
+		// Header-like lines in code blocks are message text
+		message("1700086400.000100", "U1", "```\n### Not a header - 10:00:00\n## 📅 2020-01-01\n```"),
+		message("1700172800.000100", "U2", "third day"),
+	}
+	dir := t.TempDir()
+	ch := export.Channel{
+		ID:           "C1",
+		Name:         "general",
+		UserMap:      map[string]string{"U1": "Alice Kim", "U2": "Bob Lee"},
+		TargetFolder: dir,
+		Folder:       "project",
+	}
+	path, err := export.Save(context.Background(), export.Markdown{}, ch, export.Messages(msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := mdformat.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Version != mdformat.Version {
+		t.Errorf("Version = %d, want %d", stats.Version, mdformat.Version)
+	}
+	if stats.Messages != len(msgs) {
+		t.Errorf("Messages = %d, want %d", stats.Messages, len(msgs))
+	}
+	if want := time.Unix(1700000000, 0); !stats.First.Equal(want) {
+		t.Errorf("First = %v, want %v", stats.First, want)
+	}
+	if want := time.Unix(1700172800, 0); !stats.Last.Equal(want) {
+		t.Errorf("Last = %v, want %v", stats.Last, want)
+	}
+	wantDays := map[string]int{"2023-11-14": 2, "2023-11-15": 1, "2023-11-16": 1}
+	if len(stats.PerDay) != len(wantDays) {
+		t.Errorf("PerDay = %v, want %v", stats.PerDay, wantDays)
+	}
+	for day, n := range wantDays {
+		if stats.PerDay[day] != n {
+			t.Errorf("PerDay[%s] = %d, want %d", day, stats.PerDay[day], n)
+		}
+	}
+	if day, n := stats.PeakDay(); day != "2023-11-14" || n != 2 {
+		t.Errorf("PeakDay = %s, %d; want 2023-11-14, 2", day, n)
+	}
+
+	fm := stats.FrontMatter
+	if fm == nil {
+		t.Fatal("FrontMatter = nil")
+	}
+	if fm.ChannelID != "C1" || fm.Name != "general" || fm.Folder != "project" {
+		t.Errorf("FrontMatter channel = %q %q %q, want C1 general project", fm.ChannelID, fm.Name, fm.Folder)
+	}
+	if fm.FirstMessageTS != "1700000000.000100" || fm.LastMessageTS != "1700172800.000100" || fm.MessageCount != len(msgs) {
+		t.Errorf("FrontMatter range = %s..%s (%d), want the written messages", fm.FirstMessageTS, fm.LastMessageTS, fm.MessageCount)
+	}
+	if fm.FormatVersion != mdformat.Version {
+		t.Errorf("FrontMatter.FormatVersion = %d, want %d", fm.FormatVersion, mdformat.Version)
+	}
+}
+
+// TestHeaders checks that the header helpers produce what Parse reads
+func TestHeaders(t *testing.T) {
+	at := time.Date(2025, 6, 17, 17, 14, 1, 0, time.UTC)
+	doc := mdformat.DateHeader(at) + "\n\n" + mdformat.MessageHeader("Alice Kim", at) + "\n\ntext\n"
+	stats, err := mdformat.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Messages != 1 || !stats.First.Equal(at) {
+		t.Errorf("Parse = %d messages, first %v; want 1 at %v", stats.Messages, stats.First, at)
+	}
+}
+
+// TestFrontMatterRoundTrip writes and reads back every field, including
+// strings that need escaping in YAML
+func TestFrontMatterRoundTrip(t *testing.T) {
+	want := mdformat.FrontMatter{
+		ChannelID:       "C1",
+		Name:            "general",
+		Type:            "private_channel",
+		Private:         true,
+		Folder:          "team/infra",
+		Topic:           `Deploys: "prod" only # no staging`,
+		Purpose:         "line one\nline two",
+		FirstMessageTS:  "1700000000.000100",
+		LastMessageTS:   "1700172800.000100",
+		MessageCount:    4,
+		ExportedAt:      time.Date(2025, 6, 17, 9, 0, 0, 0, time.UTC),
+		Timezone:        "Asia/Seoul",
+		Exporter:        "slack-extract",
+		ExporterVersion: "v1.2.0",
+		FormatVersion:   mdformat.Version,
+		Tags:            []string{"slack/private_channel"},
+		Aliases:         []string{"#general"},
+	}
+	var b strings.Builder
+	if err := want.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	got, err := mdformat.ReadFrontMatter(strings.NewReader(b.String() + "\n# general\n"))
+	if err != nil || got == nil {
+		t.Fatalf("ReadFrontMatter = %v, %v", got, err)
+	}
+	if got.Topic != want.Topic || got.Purpose != want.Purpose || got.Folder != want.Folder {
+		t.Errorf("strings = %q %q %q, want %q %q %q", got.Topic, got.Purpose, got.Folder, want.Topic, want.Purpose, want.Folder)
+	}
+	if got.Type != want.Type || !got.Private || got.MessageCount != want.MessageCount || !got.ExportedAt.Equal(want.ExportedAt) {
+		t.Errorf("FrontMatter = %+v, want %+v", *got, want)
+	}
+	if len(got.Tags) != 1 || got.Tags[0] != want.Tags[0] || len(got.Aliases) != 1 || got.Aliases[0] != want.Aliases[0] {
+		t.Errorf("Tags, Aliases = %v %v, want %v %v", got.Tags, got.Aliases, want.Tags, want.Aliases)
+	}
+	if !got.LastMessageTime().Equal(time.Unix(1700172800, 100000)) {
+		t.Errorf("LastMessageTime = %v", got.LastMessageTime())
+	}
+}
+
+// TestParseLegacy reads files written by earlier versions of the layout
+func TestParseLegacy(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		version int
+	}{
+		{"v1", "# general\n\nExported: 2023-11-20 10:00:00\n\n---\n\n" +
+			"### Alice Kim - 2023-11-14 22:13:20\n\nfirst\n\n---\n\n" +
+			"### Bob Lee - 2023-11-16 22:13:20\n\nlast\n\n> **Alice Kim** - 2023-11-17 09:00:00\n> reply\n\n---\n", 1},
+		{"v2", "# general\n\n<!-- slack-extract format: 2 -->\n\nExported: 2023-11-20 10:00:00\n\n---\n\n" +
+			"## 📅 2023-11-14\n\n### Alice Kim - 22:13:20\n\nfirst\n\n---\n\n" +
+			"## 📅 2023-11-16\n\n### Bob Lee - 22:13:20\n\nlast\n\n---\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := mdformat.Parse(strings.NewReader(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Version != tt.version || stats.FrontMatter != nil {
+				t.Errorf("Version = %d (front matter %v), want %d", stats.Version, stats.FrontMatter, tt.version)
+			}
+			if stats.Messages != 2 {
+				t.Errorf("Messages = %d, want 2", stats.Messages)
+			}
+			if want := time.Date(2023, 11, 16, 22, 13, 20, 0, time.UTC); !stats.Last.Equal(want) {
+				t.Errorf("Last = %v, want %v", stats.Last, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package meta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	"github.com/chanseok/slackExtract/internal/store"
+)
+
+// CheckpointDirName is the directory under .meta holding checkpoints of
+// interrupted history fetches
+const CheckpointDirName = "checkpoints"
+
+// Checkpoint records how far an interrupted history fetch got. The messages
+// of the completed pages are kept next to it in a store of their own
+// ({channelID}/{YYYY-MM}.jsonl), in the same layout as the raw store.
+type Checkpoint struct {
+	ChannelID   string    `json:"channel_id"`
+	ChannelName string    `json:"channel_name"`
+	Range       string    `json:"range,omitempty"`  // The date range as given, e.g. "30d.."
+	Oldest      string    `json:"oldest,omitempty"` // Bounds of the fetch; only the same fetch resumes it
+	Latest      string    `json:"latest,omitempty"`
+	Cursor      string    `json:"cursor"`         // Cursor of the next page
+	Done        bool      `json:"done,omitempty"` // All pages were fetched; only saving them remains
+	Pages       int       `json:"pages"`
+	Messages    int       `json:"messages"`
+	StartedAt   time.Time `json:"started_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Partial marks a channel whose download was interrupted and can be resumed
+type Partial struct {
+	StartedAt  time.Time `json:"started_at"` // When the interrupted fetch started
+	Checkpoint string    `json:"checkpoint"` // Checkpoint file, relative to the export root
+}
+
+func (m *Manager) checkpointPath(channelID string) string {
+	return filepath.Join(m.baseDir, MetaDirName, CheckpointDirName, channelID+".json")
+}
+
+// CheckpointPages returns the store holding the fetched pages of checkpoints
+func (m *Manager) CheckpointPages() *store.Store {
+	return store.NewAt(filepath.Join(m.baseDir, MetaDirName, CheckpointDirName))
+}
+
+// LoadCheckpoint returns the checkpoint of a channel, or nil if there is none
+func (m *Manager) LoadCheckpoint(channelID string) (*Checkpoint, error) {
+	data, err := os.ReadFile(m.checkpointPath(channelID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// SaveCheckpointPage adds a completed page of history to the checkpoint of
+// cp's channel and moves the checkpoint to cp.Cursor; an empty cursor marks
+// the fetch done. The channel is marked partial in the index with the first
+// page, so the index is not rewritten for every page.
+//
+// A page written before a crash but not yet recorded here is fetched again
+// on resume; the store merges the duplicates.
+func (m *Manager) SaveCheckpointPage(cp *Checkpoint, msgs []slack.Message) error {
+	pages := m.CheckpointPages()
+	if cp.Pages == 0 {
+		// A new checkpoint starts without pages
+		if err := pages.Reset(cp.ChannelID); err != nil {
+			return err
+		}
+	}
+	if err := pages.Append(cp.ChannelID, msgs); err != nil {
+		return fmt.Errorf("failed to write checkpoint pages: %w", err)
+	}
+
+	// The page is on disk; now move the checkpoint past it
+	cp.Pages++
+	cp.Messages += len(msgs)
+	cp.Done = cp.Cursor == ""
+	cp.UpdatedAt = time.Now()
+	if cp.StartedAt.IsZero() {
+		cp.StartedAt = cp.UpdatedAt
+	}
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+	// An empty page writes no messages, so the directory may not exist yet
+	path := m.checkpointPath(cp.ChannelID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+
+	if cp.Pages > 1 {
+		return nil
+	}
+	m.EnsureChannel(cp.ChannelID, cp.ChannelName)
+	m.mu.Lock()
+	m.index.Channels[cp.ChannelID].Partial = &Partial{
+		StartedAt:  cp.StartedAt,
+		Checkpoint: filepath.Join(MetaDirName, CheckpointDirName, cp.ChannelID+".json"),
+	}
+	m.mu.Unlock()
+	return m.SaveIndex()
+}
+
+// ClearCheckpoint removes the checkpoint of a channel once its history has
+// been saved, and clears its partial mark
+func (m *Manager) ClearCheckpoint(channelID string) error {
+	if err := m.CheckpointPages().Reset(channelID); err != nil {
+		return err
+	}
+	err := os.Remove(m.checkpointPath(channelID))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+
+	m.mu.Lock()
+	ch, exists := m.index.Channels[channelID]
+	wasPartial := exists && ch.Partial != nil
+	if wasPartial {
+		ch.Partial = nil
+	}
+	m.mu.Unlock()
+	if wasPartial {
+		return m.SaveIndex()
+	}
+	return nil
+}
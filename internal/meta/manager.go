@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
@@ -94,6 +95,55 @@ func (m *Manager) UpdateChannelDownload(channelID, channelName, relPath string,
 	ch.LastDownloadedAt = time.Now()
 }
 
+// RecordChannelFile records the file written for a channel in an export format
+func (m *Manager) RecordChannelFile(channelID, format, relPath string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch, exists := m.index.Channels[channelID]
+	if !exists {
+		return
+	}
+	if ch.Files == nil {
+		ch.Files = make(map[string]ExportFile)
+	}
+	ch.Files[format] = ExportFile{Path: relPath, WrittenAt: time.Now()}
+}
+
+// UpdateChannelThreads records the fetched vs. expected reply counts of threads.
+// Threads not in stats keep their previous values.
+func (m *Manager) UpdateChannelThreads(channelID string, stats map[string]ThreadStat) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch, exists := m.index.Channels[channelID]
+	if !exists {
+		return
+	}
+	if ch.Threads == nil {
+		ch.Threads = make(map[string]ThreadStat)
+	}
+	for ts, stat := range stats {
+		ch.Threads[ts] = stat
+	}
+}
+
+// UpdateChannelCoverage records spans of fetched history. With replace set
+// the previous coverage is discarded, e.g. after the history was re-downloaded.
+func (m *Manager) UpdateChannelCoverage(channelID string, spans []Span, replace bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ch, exists := m.index.Channels[channelID]
+	if !exists {
+		return
+	}
+	if replace {
+		ch.Coverage = nil
+	}
+	ch.Coverage = mergeSpans(append(ch.Coverage, spans...))
+}
+
 // EnsureChannel ensures a channel exists in the index
 func (m *Manager) EnsureChannel(id, name string) {
 	m.mu.Lock()
@@ -133,6 +183,20 @@ func (m *Manager) GetChannel(channelID string) (*Channel, bool) {
 	return ch, exists
 }
 
+// Channels returns all channels in the index, sorted by name
+func (m *Manager) Channels() []*Channel {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	channels := make([]*Channel, 0, len(m.index.Channels))
+	for _, ch := range m.index.Channels {
+		channels = append(channels, ch)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].Name < channels[j].Name
+	})
+	return channels
+}
+
 // GetChannelByName returns metadata for a specific channel by name
 func (m *Manager) GetChannelByName(name string) (*Channel, bool) {
 	m.mu.RLock()
@@ -1,6 +1,9 @@
 package meta
 
-import "time"
+import (
+	"sort"
+	"time"
+)
 
 // Index represents the global index of all exported channels
 type Index struct {
@@ -10,13 +13,89 @@ type Index struct {
 
 // Channel represents metadata for a single channel
 type Channel struct {
-	ID               string        `json:"id"`
-	Name             string        `json:"name"`
-	Path             string        `json:"path"` // Relative path to the markdown file
-	MessageCount     int           `json:"message_count"`
-	LastMessageAt    time.Time     `json:"last_message_at"`
-	LastDownloadedAt time.Time     `json:"last_downloaded_at"`
-	Analysis         *AnalysisMeta `json:"analysis,omitempty"`
+	ID               string                `json:"id"`
+	Name             string                `json:"name"`
+	Path             string                `json:"path"` // Relative path to the markdown file, or the first export file without Markdown
+	MessageCount     int                   `json:"message_count"`
+	LastMessageAt    time.Time             `json:"last_message_at"`
+	LastDownloadedAt time.Time             `json:"last_downloaded_at"`
+	Threads          map[string]ThreadStat `json:"threads,omitempty"`  // Key: thread_ts
+	Coverage         []Span                `json:"coverage,omitempty"` // Time ranges whose history has been fetched
+	Partial          *Partial              `json:"partial,omitempty"`  // Set while an interrupted download waits to be resumed
+	Files            map[string]ExportFile `json:"files,omitempty"`    // Written export files, keyed by format
+	Analysis         *AnalysisMeta         `json:"analysis,omitempty"`
+}
+
+// ExportFile is a file written for a channel in one export format
+type ExportFile struct {
+	Path      string    `json:"path"` // Relative to the export root
+	WrittenAt time.Time `json:"written_at"`
+}
+
+// ThreadStat records how completely a thread was fetched
+type ThreadStat struct {
+	ReplyCount int    `json:"reply_count"`       // reply_count reported by Slack
+	Fetched    int    `json:"fetched"`           // Number of replies actually fetched
+	Warning    string `json:"warning,omitempty"` // Why the thread is incomplete
+}
+
+// Complete reports whether all replies of the thread were fetched
+func (t ThreadStat) Complete() bool {
+	return t.Warning == "" && t.Fetched >= t.ReplyCount
+}
+
+// IncompleteThreads returns the number of threads that were not fetched completely
+func (c *Channel) IncompleteThreads() int {
+	count := 0
+	for _, t := range c.Threads {
+		if !t.Complete() {
+			count++
+		}
+	}
+	return count
+}
+
+// Span is a time range of channel history that has been fetched.
+// A zero From means the span starts at the beginning of the channel.
+type Span struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// CoveredUntil returns the end of the fetched history that continues without
+// gaps from the given time, or the zero time if from is not covered
+func (c *Channel) CoveredUntil(from time.Time) time.Time {
+	var end time.Time
+	for _, span := range mergeSpans(c.Coverage) {
+		if span.From.After(from) {
+			break
+		}
+		if !span.To.Before(from) {
+			end = span.To
+		}
+	}
+	return end
+}
+
+// mergeSpans sorts spans and joins the ones that overlap or touch
+func mergeSpans(spans []Span) []Span {
+	sorted := make([]Span, len(spans))
+	copy(sorted, spans)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].From.Before(sorted[j].From)
+	})
+
+	var merged []Span
+	for _, span := range sorted {
+		if n := len(merged); n > 0 && !span.From.After(merged[n-1].To) {
+			if span.To.After(merged[n-1].To) {
+				merged[n-1].To = span.To
+			}
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
 }
 
 // AnalysisMeta contains information about the last LLM analysis
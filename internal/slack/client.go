@@ -2,15 +2,19 @@ package slack
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"strings"
 
 	"github.com/chanseok/slackExtract/internal/config"
 	"github.com/slack-go/slack"
 )
 
-func NewClient(cfg *config.Config) (*slack.Client, *http.Client, error) {
+// NewClient connects to Slack with the token and cookie of cfg and checks
+// them with auth.test. The result is reported to out.
+func NewClient(cfg *config.Config, out io.Writer) (*slack.Client, *http.Client, error) {
 	// Create a custom HTTP client with the cookie
 	jar, _ := cookiejar.New(nil)
 	u, _ := url.Parse("https://slack.com")
@@ -28,14 +32,22 @@ func NewClient(cfg *config.Config) (*slack.Client, *http.Client, error) {
 	}
 
 	// Initialize Slack API with custom client
-	api := slack.New(cfg.UserToken, slack.OptionHTTPClient(httpClient))
+	options := []slack.Option{slack.OptionHTTPClient(httpClient)}
+	if cfg.SlackAPIURL != "" {
+		apiURL := cfg.SlackAPIURL
+		if !strings.HasSuffix(apiURL, "/") {
+			apiURL += "/"
+		}
+		options = append(options, slack.OptionAPIURL(apiURL))
+	}
+	api := slack.New(cfg.UserToken, options...)
 
 	// Auth Test
 	authTest, err := api.AuthTest()
 	if err != nil {
 		return nil, nil, fmt.Errorf("error connecting to Slack: %w", err)
 	}
-	fmt.Printf("Successfully authenticated as: %s (Team: %s)\n", authTest.User, authTest.Team)
+	fmt.Fprintf(out, "Successfully authenticated as: %s (Team: %s)\n", authTest.User, authTest.Team)
 
 	return api, httpClient, nil
 }
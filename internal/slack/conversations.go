@@ -0,0 +1,89 @@
+package slack
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// mpimName matches Slack's generated group DM names, e.g. "mpdm-alice--bob--carol-1"
+var mpimName = regexp.MustCompile(`^mpdm-(.+)-\d+$`)
+
+// NameConversations names DMs and group DMs after their participants, e.g.
+// "dm-jane.doe" or "mpdm-alice-bob-carol", since Slack leaves DM names blank.
+// known maps conversation IDs to names used by earlier syncs; those are kept
+// so a conversation's files do not move when a participant is renamed,
+// unless they are only the ID fallbacks used when no participant was known.
+// The channels are sorted by their new names.
+func NameConversations(channels []slack.Channel, users *UserDirectory, known map[string]string) {
+	for i := range channels {
+		ch := &channels[i]
+		if !ch.IsIM && !ch.IsMpIM {
+			continue
+		}
+		if name := known[ch.ID]; name != "" && !fallbackName(name, *ch) {
+			ch.Name = name
+			continue
+		}
+		ch.Name = ConversationName(ch.ID, ch.Name, ch.User, ch.IsIM, users)
+	}
+
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].Name < channels[j].Name
+	})
+}
+
+// ConversationName returns the name of a DM (from the other participant's
+// handle) or a group DM (from Slack's generated name). Other conversations
+// keep their name.
+func ConversationName(id, name, user string, isIM bool, users *UserDirectory) string {
+	if isIM {
+		handle := ""
+		if users != nil {
+			handle = users.Name(user, NameHandle)
+		}
+		if handle == "" {
+			handle = user
+		}
+		if handle == "" {
+			return id
+		}
+		return "dm-" + strings.ReplaceAll(strings.ToLower(handle), " ", "-")
+	}
+
+	if m := mpimName.FindStringSubmatch(name); m != nil {
+		return "mpdm-" + strings.ReplaceAll(m[1], "--", "-")
+	}
+	if name == "" {
+		return id
+	}
+	return name
+}
+
+// fallbackName reports whether name is what ConversationName returns for a
+// conversation whose participants are unknown: its ID, a DM named after the
+// raw user ID, or Slack's generated group DM name
+func fallbackName(name string, ch slack.Channel) bool {
+	switch {
+	case name == ch.ID:
+		return true
+	case ch.IsIM && ch.User != "" && name == "dm-"+strings.ToLower(ch.User):
+		return true
+	case ch.IsMpIM && mpimName.MatchString(name):
+		return true
+	}
+	return false
+}
+
+// missingDMUsers reports whether a channel cache predates DM participants
+// being recorded, in which case DMs cannot be named from it
+func missingDMUsers(channels []CachedChannel) bool {
+	for _, ch := range channels {
+		if ch.IsIM && ch.User == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package slack
+
+import (
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestNameConversationsKnownNames(t *testing.T) {
+	users := NewUserDirectory()
+	users.Add(slack.User{ID: "U00000001", Name: "alice"})
+
+	tests := []struct {
+		name  string
+		known string
+		want  string
+	}{
+		{"no known name", "", "dm-alice"},
+		{"kept after a rename", "dm-alice.old", "dm-alice.old"},
+		{"ID fallback", "D00000001", "dm-alice"},
+		{"raw user ID fallback", "dm-u00000001", "dm-alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := slack.Channel{}
+			ch.ID, ch.IsIM, ch.User = "D00000001", true, "U00000001"
+			channels := []slack.Channel{ch}
+			NameConversations(channels, users, map[string]string{"D00000001": tt.known})
+			if got := channels[0].Name; got != tt.want {
+				t.Errorf("name = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package fakeslack
+
+import (
+	"encoding/json"
+	"fmt"
+
+	slackgo "github.com/slack-go/slack"
+)
+
+// SampleToken is the token expected by the sample workspace
+const SampleToken = "xoxc-fake-token"
+
+// SampleWorkspace returns a small workspace exercising pagination, threads
+// with paginated replies, reactions, file attachments, Block Kit layouts,
+// legacy attachments, direct messages and a group DM
+func SampleWorkspace() *Workspace {
+	ws := &Workspace{
+		Token: SampleToken,
+		Team:  "Fake Team",
+		User:  "U00000001",
+		Users: []slackgo.User{
+			sampleUser("U00000001", "alice", "Alice Kim"),
+			sampleUser("U00000002", "bob", "Bob Lee"),
+			sampleUser("U00000003", "carol", ""),
+			sampleUser("U00000004", "dave", "Dave Park"),
+		},
+		Bots: []slackgo.Bot{
+			{ID: "B00000003", Name: "Jira Cloud", AppID: "A00000003"},
+		},
+		Messages: make(map[string][]slackgo.Message),
+		Files: map[string][]byte{
+			"F00000001/notes.txt": []byte("meeting notes\n"),
+		},
+	}
+
+	ws.Users[0].Profile.DisplayName = "alice.k"
+	ws.Users[0].Profile.Title = "Engineering Manager"
+	ws.Users[3].Deleted = true
+
+	ws.Channels = []slackgo.Channel{
+		sampleChannel("C00000001", "general", "Company-wide announcements"),
+		sampleChannel("C00000002", "random", "Off-topic"),
+	}
+	dm := slackgo.Channel{}
+	dm.ID = "D00000001"
+	dm.IsIM = true
+	dm.User = "U00000002"
+	dm.Created = slackgo.JSONTime(1700000000)
+	mpim := slackgo.Channel{}
+	mpim.ID = "G00000001"
+	mpim.Name = "mpdm-alice--bob--carol-1"
+	mpim.IsMpIM = true
+	mpim.IsPrivate = true
+	mpim.IsMember = true
+	mpim.Created = slackgo.JSONTime(1700000000)
+	ws.Channels = append(ws.Channels, dm, mpim)
+
+	base := 1700000000
+
+	// random: enough top-level messages to need several history pages
+	var random []slackgo.Message
+	for i := 0; i < 250; i++ {
+		random = append(random, sampleMessage("U00000002", fmt.Sprintf("message %d", i), base+3600+i*60, ""))
+	}
+	ws.Messages["C00000002"] = random
+
+	// general: a thread with more replies than one page, and a file
+	general := []slackgo.Message{
+		sampleMessage("U00000001", "Welcome to <#C00000001|general>!", base, ""),
+		sampleMessage("U00000002", "Thread about the release <@U00000001>", base+60, ""),
+	}
+	general[0].Reactions = []slackgo.ItemReaction{
+		{Name: "tada", Count: 2, Users: []string{"U00000002", "U00000003"}},
+		{Name: "+1", Count: 1, Users: []string{"U00000003"}},
+	}
+	general[1].ThreadTimestamp = general[1].Timestamp
+	for i := 1; i <= 150; i++ {
+		general = append(general, sampleMessage("U00000003", fmt.Sprintf("reply %d", i), base+60+i, general[1].Timestamp))
+	}
+	withFile := sampleMessage("U00000001", "Notes attached", base+600, "")
+	withFile.Files = []slackgo.File{{
+		ID:         "F00000001",
+		Name:       "notes.txt",
+		Mimetype:   "text/plain",
+		URLPrivate: "/files/F00000001/notes.txt",
+	}}
+	general = append(general, withFile)
+	general = append(general, sampleBlocksMessage("U00000002", "", sampleRichTextBlocks, base+660))
+	deploy := sampleBlocksMessage("", "Deploy finished", sampleDeployBlocks, base+720)
+	deploy.SubType = "bot_message"
+	deploy.BotID = "B00000001"
+	deploy.Username = "Deploy Bot"
+	alert := sampleMessage("", "", base+780, "")
+	alert.SubType = "bot_message"
+	alert.BotID = "B00000002"
+	alert.Username = "PagerDuty"
+	alert.Attachments = []slackgo.Attachment{{
+		Color:     "#e01e5a",
+		Fallback:  "Triggered: High error rate on api",
+		Pretext:   "New incident on *api*",
+		Title:     "#1234 High error rate on api",
+		TitleLink: "https://example.com/incidents/1234",
+		Text:      "Error rate is *12%* (threshold 5%)",
+		Fields: []slackgo.AttachmentField{
+			{Title: "Service", Value: "api", Short: true},
+			{Title: "Urgency", Value: "high", Short: true},
+		},
+		Footer: "PagerDuty",
+		Ts:     json.Number(fmt.Sprintf("%d", base+780)),
+	}}
+	shared := sampleMessage("U00000001", "FYI <@U00000003>", base+840, "")
+	shared.Attachments = []slackgo.Attachment{{
+		AuthorID:   "U00000002",
+		AuthorName: "bob",
+		Text:       "message 0",
+		FromURL:    "https://fake.slack.com/archives/C00000002/p1700003600000100",
+		Ts:         json.Number(random[0].Timestamp),
+		Footer:     "Posted in #random",
+	}}
+	// A bot posting without a username is named through bots.info
+	jira := sampleMessage("", "JIRA-42 moved to *Done*", base+900, "")
+	jira.SubType = "bot_message"
+	jira.BotID = "B00000003"
+	// A message from a user who has since been deactivated
+	former := sampleMessage("U00000004", "Handing over the on-call rotation", base+960, "")
+	ws.Messages["C00000001"] = append(general, deploy, alert, shared, jira, former)
+
+	ws.Messages["D00000001"] = []slackgo.Message{
+		sampleMessage("U00000002", "hey, got a minute?", base+7200, ""),
+		sampleMessage("U00000001", "sure", base+7260, ""),
+	}
+	ws.Messages["G00000001"] = []slackgo.Message{
+		sampleMessage("U00000003", "lunch?", base+7300, ""),
+	}
+
+	return ws
+}
+
+// sampleRichTextBlocks is what the Slack client sends for a formatted message
+const sampleRichTextBlocks = `[{"type": "rich_text", "elements": [
+	{"type": "rich_text_section", "elements": [
+		{"type": "text", "text": "Release checklist for "},
+		{"type": "user", "user_id": "U00000001"},
+		{"type": "text", "text": " ", "style": {"bold": true}},
+		{"type": "emoji", "name": "rocket"},
+		{"type": "text", "text": "\n"}
+	]},
+	{"type": "rich_text_list", "style": "ordered", "indent": 0, "elements": [
+		{"type": "rich_text_section", "elements": [{"type": "text", "text": "Tag the build", "style": {"bold": true}}]},
+		{"type": "rich_text_section", "elements": [
+			{"type": "text", "text": "Update "},
+			{"type": "link", "url": "https://example.com/changelog", "text": "the changelog"}
+		]}
+	]},
+	{"type": "rich_text_list", "style": "bullet", "indent": 1, "elements": [
+		{"type": "rich_text_section", "elements": [{"type": "text", "text": "mention ", "style": {"italic": true}}, {"type": "channel", "channel_id": "C00000002"}]}
+	]},
+	{"type": "rich_text_quote", "elements": [{"type": "text", "text": "Ship it when green\nnot before"}]},
+	{"type": "rich_text_preformatted", "elements": [{"type": "text", "text": "make release VERSION=1.2.0"}]}
+]}]`
+
+// sampleDeployBlocks is a bot notification whose text is only a fallback
+const sampleDeployBlocks = `[
+	{"type": "header", "text": {"type": "plain_text", "text": "Deploy finished"}},
+	{"type": "section", "text": {"type": "mrkdwn", "text": "*api* was deployed to <https://example.com/prod|production>"},
+		"fields": [
+			{"type": "mrkdwn", "text": "*Version*\n1.2.0"},
+			{"type": "mrkdwn", "text": "*Author*\n<@U00000002>"}
+		]},
+	{"type": "divider"},
+	{"type": "image", "image_url": "https://example.com/graph.png", "alt_text": "latency graph"},
+	{"type": "context", "elements": [
+		{"type": "image", "image_url": "https://example.com/icon.png", "alt_text": "ci"},
+		{"type": "mrkdwn", "text": "Took 3m 12s"}
+	]},
+	{"type": "actions", "elements": [{"type": "button", "text": {"type": "plain_text", "text": "Rollback"}, "action_id": "rollback"}]}
+]`
+
+func sampleBlocksMessage(user, text, blocks string, unix int) slackgo.Message {
+	m := sampleMessage(user, text, unix, "")
+	if err := json.Unmarshal([]byte(blocks), &m.Blocks); err != nil {
+		panic(fmt.Sprintf("invalid sample blocks: %v", err))
+	}
+	return m
+}
+
+func sampleUser(id, name, realName string) slackgo.User {
+	return slackgo.User{
+		ID:       id,
+		Name:     name,
+		RealName: realName,
+		Profile:  slackgo.UserProfile{RealName: realName, DisplayName: name},
+	}
+}
+
+func sampleChannel(id, name, purpose string) slackgo.Channel {
+	ch := slackgo.Channel{}
+	ch.ID = id
+	ch.Name = name
+	ch.IsChannel = true
+	ch.IsMember = true
+	ch.Created = slackgo.JSONTime(1700000000)
+	ch.Purpose = slackgo.Purpose{Value: purpose}
+	return ch
+}
+
+func sampleMessage(user, text string, unix int, threadTS string) slackgo.Message {
+	m := slackgo.Message{}
+	m.Type = "message"
+	m.User = user
+	m.Text = text
+	m.Timestamp = fmt.Sprintf("%d.000100", unix)
+	m.ThreadTimestamp = threadTS
+	return m
+}
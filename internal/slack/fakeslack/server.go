@@ -0,0 +1,433 @@
+package fakeslack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	slackgo "github.com/slack-go/slack"
+)
+
+// Workspace is the data served by the fake Slack API
+type Workspace struct {
+	Token    string // Expected token; any token is accepted when empty
+	Team     string
+	User     string // Authenticated user ID
+	Channels []slackgo.Channel
+	Users    []slackgo.User
+	Bots     []slackgo.Bot // Served by bots.info
+	// Messages per channel ID. Thread replies are listed here too (with
+	// thread_ts set); history returns only top-level messages like Slack does.
+	Messages map[string][]slackgo.Message
+	Files    map[string][]byte // Served under /files/{name}
+}
+
+// Server is an in-process fake of the Slack Web API covering the methods
+// used by slack-extract: auth.test, conversations.list, conversations.history,
+// conversations.replies, users.list, bots.info and file downloads.
+type Server struct {
+	ws       *Workspace
+	PageSize int // Items per page when the client does not ask for fewer
+
+	dataMu   sync.RWMutex // Guards ws while the server is running
+	mu       sync.Mutex
+	throttle map[string]throttle
+	calls    map[string]int
+	http     *httptest.Server
+}
+
+type throttle struct {
+	remaining  int
+	retryAfter int // Seconds
+}
+
+// New creates a fake server for the workspace. Use Start for an
+// in-process server or Handler to serve it on a fixed address.
+func New(ws *Workspace) *Server {
+	if ws.Messages == nil {
+		ws.Messages = make(map[string][]slackgo.Message)
+	}
+	return &Server{
+		ws:       ws,
+		PageSize: 100,
+		throttle: make(map[string]throttle),
+		calls:    make(map[string]int),
+	}
+}
+
+// Start serves the fake API on a local port until Close is called
+func (s *Server) Start() {
+	s.http = httptest.NewServer(s.Handler())
+}
+
+// Close stops a server started with Start
+func (s *Server) Close() {
+	if s.http != nil {
+		s.http.Close()
+	}
+}
+
+// URL returns the base URL of a started server
+func (s *Server) URL() string {
+	return s.http.URL
+}
+
+// APIURL returns the value for slack.OptionAPIURL (and SLACK_API_URL)
+func (s *Server) APIURL() string {
+	return s.URL() + "/api/"
+}
+
+// RateLimit makes the next count calls of an API method (e.g.
+// "conversations.history") fail with HTTP 429 and a Retry-After header
+func (s *Server) RateLimit(method string, count, retryAfterSeconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if count <= 0 {
+		delete(s.throttle, method)
+		return
+	}
+	s.throttle[method] = throttle{remaining: count, retryAfter: retryAfterSeconds}
+}
+
+// Calls returns how often an API method was called, rate-limited calls included
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// PostMessage adds a message or, with thread_ts set, a reply to a channel
+func (s *Server) PostMessage(channelID string, msg slackgo.Message) {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	s.ws.Messages[channelID] = append(s.ws.Messages[channelID], msg)
+}
+
+// EditMessage changes the text of a message or reply and sets its edited.ts
+func (s *Server) EditMessage(channelID, ts, text string) bool {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	for i, m := range s.ws.Messages[channelID] {
+		if m.Timestamp == ts {
+			m.Text = text
+			m.Edited = &slackgo.Edited{User: m.User, Timestamp: fmt.Sprintf("%d.000000", time.Now().Unix())}
+			s.ws.Messages[channelID][i] = m
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteMessage deletes a message or reply like Slack does: a thread parent
+// with replies becomes a tombstone, anything else disappears
+func (s *Server) DeleteMessage(channelID, ts string) bool {
+	s.dataMu.Lock()
+	defer s.dataMu.Unlock()
+	msgs := s.ws.Messages[channelID]
+	for i, m := range msgs {
+		if m.Timestamp != ts {
+			continue
+		}
+		hasReplies := false
+		for _, r := range msgs {
+			if r.ThreadTimestamp == ts && r.Timestamp != ts {
+				hasReplies = true
+				break
+			}
+		}
+		if hasReplies {
+			m.SubType = "tombstone"
+			m.Text = "This message was deleted."
+			m.User = ""
+			m.Files = nil
+			msgs[i] = m
+		} else {
+			s.ws.Messages[channelID] = append(msgs[:i:i], msgs[i+1:]...)
+		}
+		return true
+	}
+	return false
+}
+
+// Handler returns the HTTP handler of the fake API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", s.serveAPI)
+	mux.HandleFunc("/files/", s.serveFile)
+	return mux
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	method := strings.TrimPrefix(r.URL.Path, "/api/")
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.calls[method]++
+	t, throttled := s.throttle[method]
+	if throttled {
+		if t.remaining--; t.remaining > 0 {
+			s.throttle[method] = t
+		} else {
+			delete(s.throttle, method)
+		}
+	}
+	s.mu.Unlock()
+	if throttled {
+		w.Header().Set("Retry-After", strconv.Itoa(t.retryAfter))
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	if !s.authorized(r) {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "invalid_auth"})
+		return
+	}
+
+	s.dataMu.RLock()
+	defer s.dataMu.RUnlock()
+
+	switch method {
+	case "auth.test":
+		writeJSON(w, map[string]interface{}{
+			"ok":      true,
+			"url":     "https://fake.slack.com/",
+			"team":    s.ws.Team,
+			"user":    s.userName(s.ws.User),
+			"team_id": "T00000000",
+			"user_id": s.ws.User,
+		})
+	case "conversations.list":
+		s.conversationsList(w, r)
+	case "conversations.history":
+		s.conversationsHistory(w, r)
+	case "conversations.replies":
+		s.conversationsReplies(w, r)
+	case "users.list":
+		s.usersList(w, r)
+	case "bots.info":
+		s.botsInfo(w, r)
+	default:
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "unknown_method"})
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.ws.Token == "" {
+		return true
+	}
+	token := r.FormValue("token")
+	if token == "" {
+		token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	return token == s.ws.Token
+}
+
+func (s *Server) conversationsList(w http.ResponseWriter, r *http.Request) {
+	types := r.FormValue("types")
+	var channels []slackgo.Channel
+	for _, ch := range s.ws.Channels {
+		if types == "" || strings.Contains(types, channelType(ch)) {
+			channels = append(channels, ch)
+		}
+	}
+
+	start, end, next := s.page(r, len(channels))
+	writeJSON(w, map[string]interface{}{
+		"ok":                true,
+		"channels":          channels[start:end],
+		"response_metadata": map[string]string{"next_cursor": next},
+	})
+}
+
+func (s *Server) conversationsHistory(w http.ResponseWriter, r *http.Request) {
+	channelID := r.FormValue("channel")
+	all, ok := s.ws.Messages[channelID]
+	if !ok {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "channel_not_found"})
+		return
+	}
+	oldest, latest := r.FormValue("oldest"), r.FormValue("latest")
+
+	// Newest first, top-level messages only, with thread metadata filled in
+	var msgs []slackgo.Message
+	for _, m := range all {
+		if m.ThreadTimestamp != "" && m.ThreadTimestamp != m.Timestamp {
+			continue
+		}
+		if oldest != "" && !tsAfter(m.Timestamp, oldest) {
+			continue
+		}
+		if latest != "" && !tsAfter(latest, m.Timestamp) {
+			continue
+		}
+		msgs = append(msgs, s.withThreadInfo(r, channelID, m))
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		return tsAfter(msgs[i].Timestamp, msgs[j].Timestamp)
+	})
+
+	start, end, next := s.page(r, len(msgs))
+	writeJSON(w, map[string]interface{}{
+		"ok":                true,
+		"messages":          msgs[start:end],
+		"has_more":          next != "",
+		"response_metadata": map[string]string{"next_cursor": next},
+	})
+}
+
+func (s *Server) conversationsReplies(w http.ResponseWriter, r *http.Request) {
+	channelID, threadTS := r.FormValue("channel"), r.FormValue("ts")
+	all, ok := s.ws.Messages[channelID]
+	if !ok {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "channel_not_found"})
+		return
+	}
+
+	// Parent first, then replies oldest to newest
+	var msgs []slackgo.Message
+	for _, m := range all {
+		if m.Timestamp == threadTS || m.ThreadTimestamp == threadTS {
+			msgs = append(msgs, s.withThreadInfo(r, channelID, m))
+		}
+	}
+	if len(msgs) == 0 {
+		writeJSON(w, map[string]interface{}{"ok": false, "error": "thread_not_found"})
+		return
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		return tsAfter(msgs[j].Timestamp, msgs[i].Timestamp)
+	})
+
+	start, end, next := s.page(r, len(msgs))
+	writeJSON(w, map[string]interface{}{
+		"ok":                true,
+		"messages":          msgs[start:end],
+		"has_more":          next != "",
+		"response_metadata": map[string]string{"next_cursor": next},
+	})
+}
+
+func (s *Server) usersList(w http.ResponseWriter, r *http.Request) {
+	start, end, next := s.page(r, len(s.ws.Users))
+	writeJSON(w, map[string]interface{}{
+		"ok":                true,
+		"members":           s.ws.Users[start:end],
+		"response_metadata": map[string]string{"next_cursor": next},
+	})
+}
+
+func (s *Server) botsInfo(w http.ResponseWriter, r *http.Request) {
+	id := r.FormValue("bot")
+	for _, b := range s.ws.Bots {
+		if b.ID == id {
+			writeJSON(w, map[string]interface{}{"ok": true, "bot": b})
+			return
+		}
+	}
+	writeJSON(w, map[string]interface{}{"ok": false, "error": "bot_not_found"})
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	s.dataMu.RLock()
+	defer s.dataMu.RUnlock()
+	data, ok := s.ws.Files[strings.TrimPrefix(r.URL.Path, "/files/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write(data)
+}
+
+// withThreadInfo fills in reply_count/latest_reply for thread parents and
+// turns relative file URLs into URLs of this server
+func (s *Server) withThreadInfo(r *http.Request, channelID string, m slackgo.Message) slackgo.Message {
+	if m.ThreadTimestamp == m.Timestamp {
+		m.ReplyCount, m.LatestReply = 0, ""
+		for _, reply := range s.ws.Messages[channelID] {
+			if reply.ThreadTimestamp == m.Timestamp && reply.Timestamp != m.Timestamp {
+				m.ReplyCount++
+				if tsAfter(reply.Timestamp, m.LatestReply) {
+					m.LatestReply = reply.Timestamp
+				}
+			}
+		}
+	}
+
+	if len(m.Files) > 0 {
+		files := make([]slackgo.File, len(m.Files))
+		copy(files, m.Files)
+		for i := range files {
+			files[i].URLPrivate = s.absolute(r, files[i].URLPrivate)
+			files[i].URLPrivateDownload = s.absolute(r, files[i].URLPrivateDownload)
+		}
+		m.Files = files
+	}
+	return m
+}
+
+func (s *Server) absolute(r *http.Request, url string) string {
+	if !strings.HasPrefix(url, "/") {
+		return url
+	}
+	return "http://" + r.Host + url
+}
+
+// page applies cursor/limit pagination. Cursors are plain offsets.
+func (s *Server) page(r *http.Request, total int) (start, end int, next string) {
+	limit := s.PageSize
+	if v, err := strconv.Atoi(r.FormValue("limit")); err == nil && v > 0 && v < limit {
+		limit = v
+	}
+	if v, err := strconv.Atoi(r.FormValue("cursor")); err == nil && v > 0 && v <= total {
+		start = v
+	}
+	end = start + limit
+	if end >= total {
+		return start, total, ""
+	}
+	return start, end, strconv.Itoa(end)
+}
+
+func (s *Server) userName(id string) string {
+	for _, u := range s.ws.Users {
+		if u.ID == id {
+			return u.Name
+		}
+	}
+	return id
+}
+
+func channelType(ch slackgo.Channel) string {
+	switch {
+	case ch.IsIM:
+		return "im"
+	case ch.IsMpIM:
+		return "mpim"
+	case ch.IsPrivate:
+		return "private_channel"
+	}
+	return "public_channel"
+}
+
+// tsAfter reports whether Slack timestamp a is later than b
+func tsAfter(a, b string) bool {
+	fa, _ := strconv.ParseFloat(a, 64)
+	fb, _ := strconv.ParseFloat(b, 64)
+	return fa > fb
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode response: %v", err), http.StatusInternalServerError)
+	}
+}
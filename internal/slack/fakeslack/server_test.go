@@ -0,0 +1,271 @@
+package fakeslack
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	slackgo "github.com/slack-go/slack"
+)
+
+const releaseThread = "1700000060.000100"
+
+// start serves the sample workspace and returns a client for it
+func start(t *testing.T) (*Server, *slackgo.Client) {
+	t.Helper()
+	srv := New(SampleWorkspace())
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, slackgo.New(SampleToken, slackgo.OptionAPIURL(srv.APIURL()))
+}
+
+func TestAuth(t *testing.T) {
+	srv, client := start(t)
+	resp, err := client.AuthTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.UserID != "U00000001" || resp.User != "alice" || resp.Team != "Fake Team" {
+		t.Errorf("auth.test = %+v", resp)
+	}
+
+	bad := slackgo.New("xoxc-wrong", slackgo.OptionAPIURL(srv.APIURL()))
+	if _, err := bad.AuthTest(); err == nil || err.Error() != "invalid_auth" {
+		t.Errorf("wrong token: err = %v, want invalid_auth", err)
+	}
+}
+
+func TestConversationsList(t *testing.T) {
+	tests := []struct {
+		types []string
+		want  int
+	}{
+		{nil, 4},
+		{[]string{"public_channel"}, 2},
+		{[]string{"im"}, 1},
+		{[]string{"mpim", "im"}, 2},
+	}
+	for _, tt := range tests {
+		_, client := start(t)
+		channels, _, err := client.GetConversations(&slackgo.GetConversationsParameters{Types: tt.types})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(channels) != tt.want {
+			t.Errorf("types %v: got %d channels, want %d", tt.types, len(channels), tt.want)
+		}
+	}
+}
+
+func TestConversationsHistory(t *testing.T) {
+	srv, client := start(t)
+	srv.PageSize = 100
+
+	params := &slackgo.GetConversationHistoryParameters{ChannelID: "C00000002"}
+	var texts []string
+	pages := 0
+	for {
+		resp, err := client.GetConversationHistory(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, m := range resp.Messages {
+			texts = append(texts, m.Text)
+		}
+		if !resp.HasMore {
+			break
+		}
+		params.Cursor = resp.ResponseMetaData.NextCursor
+	}
+	if pages != 3 || len(texts) != 250 || texts[0] != "message 249" || texts[249] != "message 0" {
+		t.Errorf("got %d messages in %d pages, want 250 newest first in 3", len(texts), pages)
+	}
+
+	// Replies are left out and counted on their parent
+	resp, err := client.GetConversationHistory(&slackgo.GetConversationHistoryParameters{ChannelID: "C00000001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Messages) != 9 {
+		t.Errorf("general: got %d messages, want 9", len(resp.Messages))
+	}
+	for _, m := range resp.Messages {
+		if m.Timestamp == releaseThread && (m.ReplyCount != 150 || m.LatestReply != "1700000210.000100") {
+			t.Errorf("release thread: reply_count %d, latest_reply %q", m.ReplyCount, m.LatestReply)
+		}
+	}
+
+	// Bounds are exclusive, like Slack's
+	resp, err = client.GetConversationHistory(&slackgo.GetConversationHistoryParameters{
+		ChannelID: "C00000002",
+		Oldest:    "1700003600.000100",
+		Latest:    "1700003780.000100",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Messages) != 2 || resp.Messages[0].Text != "message 2" || resp.Messages[1].Text != "message 1" {
+		t.Errorf("bounded history = %d messages, want message 2 and message 1", len(resp.Messages))
+	}
+
+	if _, err := client.GetConversationHistory(&slackgo.GetConversationHistoryParameters{ChannelID: "C99999999"}); err == nil || err.Error() != "channel_not_found" {
+		t.Errorf("unknown channel: err = %v, want channel_not_found", err)
+	}
+}
+
+func TestConversationsReplies(t *testing.T) {
+	srv, client := start(t)
+	srv.PageSize = 100
+
+	params := &slackgo.GetConversationRepliesParameters{ChannelID: "C00000001", Timestamp: releaseThread}
+	var msgs []slackgo.Message
+	for {
+		page, hasMore, next, err := client.GetConversationReplies(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, page...)
+		if !hasMore {
+			break
+		}
+		params.Cursor = next
+	}
+	if len(msgs) != 151 || msgs[0].Timestamp != releaseThread || msgs[1].Text != "reply 1" || msgs[150].Text != "reply 150" {
+		t.Errorf("got %d messages, want the parent and then reply 1 to reply 150", len(msgs))
+	}
+
+	_, _, _, err := client.GetConversationReplies(&slackgo.GetConversationRepliesParameters{ChannelID: "C00000001", Timestamp: "1.000000"})
+	if err == nil || err.Error() != "thread_not_found" {
+		t.Errorf("unknown thread: err = %v, want thread_not_found", err)
+	}
+}
+
+func TestUsersAndBots(t *testing.T) {
+	srv, client := start(t)
+	srv.PageSize = 3
+
+	users, err := client.GetUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 4 || users[3].ID != "U00000004" || !users[3].Deleted {
+		t.Errorf("users.list = %d users, want all 4 across pages", len(users))
+	}
+
+	bot, err := client.GetBotInfo(slackgo.GetBotInfoParameters{Bot: "B00000003"})
+	if err != nil || bot.Name != "Jira Cloud" {
+		t.Errorf("bots.info = %+v, %v, want Jira Cloud", bot, err)
+	}
+	if _, err := client.GetBotInfo(slackgo.GetBotInfoParameters{Bot: "B99999999"}); err == nil || err.Error() != "bot_not_found" {
+		t.Errorf("unknown bot: err = %v, want bot_not_found", err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	srv, client := start(t)
+	resp, err := client.GetConversationHistory(&slackgo.GetConversationHistoryParameters{ChannelID: "C00000001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := ""
+	for _, m := range resp.Messages {
+		if len(m.Files) > 0 {
+			url = m.Files[0].URLPrivate
+		}
+	}
+	if url != srv.URL()+"/files/F00000001/notes.txt" {
+		t.Fatalf("file URL = %q, want one on the fake server", url)
+	}
+
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "meeting notes\n" {
+		t.Errorf("file = %q, want the notes", body)
+	}
+
+	res, err = http.Get(srv.URL() + "/files/F99999999/missing.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("missing file: status %d, want 404", res.StatusCode)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	srv, client := start(t)
+	srv.RateLimit("auth.test", 2, 7)
+
+	for i := 0; i < 2; i++ {
+		_, err := client.AuthTest()
+		var limited *slackgo.RateLimitedError
+		if !errors.As(err, &limited) || limited.RetryAfter != 7*time.Second {
+			t.Errorf("call %d: err = %v, want rate limited for 7s", i+1, err)
+		}
+	}
+	if _, err := client.AuthTest(); err != nil {
+		t.Errorf("call 3: err = %v, want success", err)
+	}
+	if calls := srv.Calls("auth.test"); calls != 3 {
+		t.Errorf("auth.test called %d times, want 3", calls)
+	}
+}
+
+func TestPostEditDelete(t *testing.T) {
+	srv, client := start(t)
+	history := func() []slackgo.Message {
+		t.Helper()
+		resp, err := client.GetConversationHistory(&slackgo.GetConversationHistoryParameters{ChannelID: "D00000001"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Messages
+	}
+
+	posted := slackgo.Message{}
+	posted.Type, posted.User, posted.Text, posted.Timestamp = "message", "U00000002", "see you", "1700007400.000100"
+	srv.PostMessage("D00000001", posted)
+	if msgs := history(); len(msgs) != 3 || msgs[0].Text != "see you" {
+		t.Fatalf("history after post = %d messages, want the new one first", len(msgs))
+	}
+
+	if !srv.EditMessage("D00000001", posted.Timestamp, "see you soon") || srv.EditMessage("D00000001", "1.000000", "x") {
+		t.Error("EditMessage did not find exactly the posted message")
+	}
+	if msgs := history(); msgs[0].Text != "see you soon" || msgs[0].Edited == nil {
+		t.Errorf("edited message = %+v", msgs[0])
+	}
+
+	if !srv.DeleteMessage("D00000001", posted.Timestamp) {
+		t.Fatal("DeleteMessage did not find the posted message")
+	}
+	if msgs := history(); len(msgs) != 2 {
+		t.Errorf("history after delete = %d messages, want 2", len(msgs))
+	}
+
+	// A thread parent with replies is kept as a tombstone
+	if !srv.DeleteMessage("C00000001", releaseThread) {
+		t.Fatal("release thread not found")
+	}
+	_, _, _, err := client.GetConversationReplies(&slackgo.GetConversationRepliesParameters{ChannelID: "C00000001", Timestamp: releaseThread})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.GetConversationHistory(&slackgo.GetConversationHistoryParameters{ChannelID: "C00000001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range resp.Messages {
+		if m.Timestamp == releaseThread && (m.SubType != "tombstone" || m.ReplyCount != 150) {
+			t.Errorf("deleted parent = %+v, want a tombstone with its replies", m)
+		}
+	}
+}
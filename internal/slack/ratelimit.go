@@ -0,0 +1,141 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Tier is a Slack Web API rate limit tier
+// See https://api.slack.com/apis/rate-limits
+type Tier int
+
+const (
+	Tier1 Tier = iota + 1 // ~1 request per minute
+	Tier2                 // ~20 requests per minute
+	Tier3                 // ~50 requests per minute
+	Tier4                 // ~100 requests per minute
+)
+
+// requestsPerMinute returns the sustained rate allowed for a tier
+func (t Tier) requestsPerMinute() int {
+	switch t {
+	case Tier1:
+		return 1
+	case Tier2:
+		return 20
+	case Tier3:
+		return 50
+	default:
+		return 100
+	}
+}
+
+// burst returns how many calls of a tier may be made back to back before
+// calls are paced at the sustained rate. Slack tolerates short bursts.
+func (t Tier) burst() float64 {
+	switch t {
+	case Tier1:
+		return 1
+	case Tier2:
+		return 3
+	case Tier3:
+		return 5
+	default:
+		return 10
+	}
+}
+
+// MethodTiers maps the operation names passed to withRetry to their Slack tier.
+// Operations not listed here are not paced by the limiter.
+var MethodTiers = map[string]Tier{
+	"GetConversationHistory": Tier3, // conversations.history
+	"GetConversationReplies": Tier3, // conversations.replies
+	"GetConversations":       Tier2, // conversations.list
+	"GetUsers":               Tier2, // users.list
+	"GetBotInfo":             Tier3, // bots.info
+	"DownloadFile":           Tier4, // files.slack.com has no published tier; kept well below it
+}
+
+// RateLimiter paces Slack API calls with a token bucket per method, so that
+// several download workers share one workspace-wide budget and calls are
+// spaced out before Slack answers with 429.
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// bucket holds the calls a method may make right away. Tokens go negative
+// when calls are queued; each queued call waits for its token to refill.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter using MethodTiers
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Wait blocks until a call to the given operation is allowed or ctx is done.
+// Waits longer than normal pacing (twice the tier's interval, e.g. when
+// several workers share the budget) are reported through progress (if set).
+func (l *RateLimiter) Wait(ctx context.Context, operation string, progress ProgressCallback) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	tier, ok := MethodTiers[operation]
+	if !ok {
+		return ctx.Err()
+	}
+
+	wait := l.reserve(operation, tier, time.Now())
+	interval := time.Minute / time.Duration(tier.requestsPerMinute())
+	if wait > 2*interval && progress != nil {
+		progress(0, 0, fmt.Sprintf("⏳ Pacing %s (tier %d): waiting %v...", operation, tier, wait.Round(time.Second)))
+	}
+	return sleep(ctx, wait)
+}
+
+// reserve takes a token for one call and returns how long the call has to
+// wait for it
+func (l *RateLimiter) reserve(operation string, tier Tier, now time.Time) time.Duration {
+	rate := float64(tier.requestsPerMinute()) / time.Minute.Seconds() // Tokens per second
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[operation]
+	if !ok {
+		b = &bucket{tokens: tier.burst(), last: now}
+		l.buckets[operation] = b
+	}
+
+	// Refill for the time since the last call, up to the burst size
+	b.tokens = math.Min(tier.burst(), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// sleep waits for d, returning early with ctx's error if it is cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,115 @@
+package slack
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// near reports whether two waits differ by less than a millisecond, since
+// waits are computed in floating point
+func near(a, b time.Duration) bool {
+	d := a - b
+	return d > -time.Millisecond && d < time.Millisecond
+}
+
+func TestRateLimiterTiers(t *testing.T) {
+	tests := []struct {
+		tier     Tier
+		burst    int
+		interval time.Duration
+	}{
+		{Tier1, 1, time.Minute},
+		{Tier2, 3, 3 * time.Second},
+		{Tier3, 5, 1200 * time.Millisecond},
+		{Tier4, 10, 600 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		l := NewRateLimiter()
+		now := time.Now()
+		// The burst goes through right away, then calls queue up one
+		// interval apart
+		for i := 1; i <= tt.burst+3; i++ {
+			want := time.Duration(0)
+			if i > tt.burst {
+				want = time.Duration(i-tt.burst) * tt.interval
+			}
+			if got := l.reserve("op", tt.tier, now); !near(got, want) {
+				t.Errorf("tier %d, call %d: wait %v, want %v", tt.tier, i, got, want)
+			}
+		}
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	l := NewRateLimiter()
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		l.reserve("op", Tier3, now)
+	}
+
+	tests := []struct {
+		after time.Duration // Since the previous call
+		want  time.Duration
+	}{
+		{0, 1200 * time.Millisecond},
+		{1200 * time.Millisecond, 1200 * time.Millisecond}, // One token refilled, one more queued
+		{3600 * time.Millisecond, 0},                       // Caught up on the queue and refilled one
+		{time.Hour, 0},                                     // Refills stop at the burst size
+		{0, 0},
+		{0, 0},
+		{0, 0},
+		{0, 0},
+		{0, 1200 * time.Millisecond},
+	}
+	for i, tt := range tests {
+		now = now.Add(tt.after)
+		if got := l.reserve("op", Tier3, now); !near(got, tt.want) {
+			t.Errorf("call %d: wait %v, want %v", i+1, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterSeparateMethods(t *testing.T) {
+	l := NewRateLimiter()
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		l.reserve("GetUsers", Tier2, now)
+	}
+	if got := l.reserve("GetUsers", Tier2, now); got == 0 {
+		t.Error("fourth users call was not paced")
+	}
+	if got := l.reserve("GetConversations", Tier2, now); got != 0 {
+		t.Errorf("first conversations call waits %v, want its own budget", got)
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	var nilLimiter *RateLimiter
+	if err := nilLimiter.Wait(context.Background(), "GetUsers", nil); err != nil {
+		t.Errorf("nil limiter: %v", err)
+	}
+
+	l := NewRateLimiter()
+	for i := 0; i < 100; i++ {
+		if err := l.Wait(context.Background(), "NotPaced", nil); err != nil {
+			t.Fatalf("unknown operation: %v", err)
+		}
+	}
+
+	// Long waits are reported, and a cancelled wait returns right away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var reports []string
+	progress := func(_, _ int, status string) { reports = append(reports, status) }
+	for i := 0; i < 6; i++ {
+		err := l.Wait(ctx, "GetUsers", progress)
+		if i < 3 && err != context.Canceled {
+			t.Errorf("call %d: err = %v, want context.Canceled", i+1, err)
+		}
+	}
+	if len(reports) != 1 || !strings.Contains(reports[0], "Pacing GetUsers (tier 2)") {
+		t.Errorf("reports = %q, want one for the sixth call", reports)
+	}
+}
@@ -1,7 +1,9 @@
 package slack
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -13,6 +15,7 @@ type RetryConfig struct {
 	MaxRetries     int           // Maximum number of retries
 	InitialBackoff time.Duration // Initial backoff duration
 	MaxBackoff     time.Duration // Maximum backoff duration
+	Limiter        *RateLimiter  // Shared limiter that paces calls before they are made (optional)
 }
 
 // DefaultRetryConfig returns sensible defaults for Slack API
@@ -44,13 +47,19 @@ func isRateLimitError(err error) (bool, time.Duration) {
 	return false, 0
 }
 
-// withRetry executes a function with retry logic for rate limits
-func withRetry[T any](cfg RetryConfig, operation string, fn func() (T, error)) (T, error) {
+// withRetry executes a function with retry logic for rate limits.
+// Waits, both for the limiter and after a 429, are reported through
+// progress (with zero counts) instead of being printed, since stdout may
+// belong to the TUI. Waiting stops as soon as ctx is cancelled.
+func withRetry[T any](ctx context.Context, cfg RetryConfig, operation string, progress ProgressCallback, fn func() (T, error)) (T, error) {
 	var result T
 	var lastErr error
 	backoff := cfg.InitialBackoff
 
 	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
+		if err := cfg.Limiter.Wait(ctx, operation, progress); err != nil {
+			return result, err
+		}
 		result, lastErr = fn()
 		if lastErr == nil {
 			return result, nil
@@ -77,10 +86,14 @@ func withRetry[T any](cfg RetryConfig, operation string, fn func() (T, error)) (
 			waitTime = cfg.MaxBackoff
 		}
 
-		fmt.Printf("⏳ Rate limited on %s. Waiting %v before retry (%d/%d)...\n",
-			operation, waitTime.Round(time.Second), attempt+1, cfg.MaxRetries)
+		if progress != nil {
+			progress(0, 0, fmt.Sprintf("⏳ Rate limited on %s. Waiting %v before retry (%d/%d)...",
+				operation, waitTime.Round(time.Second), attempt+1, cfg.MaxRetries))
+		}
 
-		time.Sleep(waitTime)
+		if err := sleep(ctx, waitTime); err != nil {
+			return result, err
+		}
 
 		// Exponential backoff for next attempt
 		backoff *= 2
@@ -93,79 +106,169 @@ func withRetry[T any](cfg RetryConfig, operation string, fn func() (T, error)) (
 }
 
 // FetchChannelsWithRetry fetches channels with automatic retry on rate limit
-func FetchChannelsWithRetry(client *slack.Client, cfg RetryConfig, refresh bool) ([]slack.Channel, error) {
-	return withRetry(cfg, "GetConversations", func() ([]slack.Channel, error) {
-		return FetchChannels(client, refresh)
+func FetchChannelsWithRetry(ctx context.Context, client *slack.Client, cfg RetryConfig, refresh bool, out io.Writer, progress ProgressCallback) ([]slack.Channel, error) {
+	return withRetry(ctx, cfg, "GetConversations", progress, func() ([]slack.Channel, error) {
+		return FetchChannels(client, refresh, out)
 	})
 }
 
-// FetchUsersWithRetry fetches users with automatic retry on rate limit
-func FetchUsersWithRetry(client *slack.Client, cfg RetryConfig, refresh bool) (map[string]string, error) {
-	return withRetry(cfg, "GetUsers", func() (map[string]string, error) {
-		return FetchUsers(client, refresh)
-	})
+// FetchUsersWithRetry fetches the user directory with automatic retry on rate limit
+func FetchUsersWithRetry(ctx context.Context, client *slack.Client, cfg RetryConfig, refresh bool, out io.Writer, progress ProgressCallback) (*UserDirectory, error) {
+	return FetchUserDirectory(ctx, client, cfg, refresh, out, progress)
 }
 
-// FetchHistoryWithRetryAndProgress fetches channel history with retry and progress callback
-func FetchHistoryWithRetryAndProgress(client *slack.Client, channelID string, cfg RetryConfig, callback ProgressCallback, oldest string) ([]Message, error) {
+// FetchHistoryWithRetryAndProgress fetches channel history with retry and progress callback.
+// oldest and latest are optional Slack timestamps bounding the history.
+// It stops with ctx's error when ctx is cancelled.
+func FetchHistoryWithRetryAndProgress(ctx context.Context, client *slack.Client, channelID string, cfg RetryConfig, callback ProgressCallback, oldest, latest string) ([]Message, error) {
 	var allMessages []Message
+	err := FetchHistoryPages(ctx, client, channelID, cfg, callback, oldest, latest, "", 0, func(msgs []Message, _ string) error {
+		allMessages = append(allMessages, msgs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allMessages, nil
+}
+
+// PageFunc receives each completed page of history (threads included)
+// together with the cursor of the next page, which is empty after the last
+// page. Returning an error stops the fetch.
+type PageFunc func(msgs []Message, nextCursor string) error
+
+// FetchHistoryPages fetches channel history page by page, starting at cursor
+// (empty for the first page). fetched is the number of messages received
+// before cursor and only affects progress reports. A page is handed to
+// onPage only once all of its threads have been fetched.
+func FetchHistoryPages(ctx context.Context, client *slack.Client, channelID string, cfg RetryConfig, callback ProgressCallback, oldest, latest, cursor string, fetched int, onPage PageFunc) error {
 	params := &slack.GetConversationHistoryParameters{
 		ChannelID: channelID,
+		Cursor:    cursor,
 		Limit:     200, // Smaller batches to reduce rate limit impact
 		Oldest:    oldest,
+		Latest:    latest,
+	}
+
+	if callback != nil {
+		callback(fetched, 0, "Fetching history...")
 	}
 
+	// Waits are reported at the number of messages fetched so far
+	var waiting ProgressCallback
 	if callback != nil {
-		callback(0, 0, "Fetching history...")
+		waiting = func(_, _ int, status string) {
+			callback(fetched, 0, status)
+		}
 	}
 
 	for {
 		// Fetch history with retry
-		history, err := withRetry(cfg, "GetConversationHistory", func() (*slack.GetConversationHistoryResponse, error) {
-			return client.GetConversationHistory(params)
+		history, err := withRetry(ctx, cfg, "GetConversationHistory", waiting, func() (*slack.GetConversationHistoryResponse, error) {
+			return client.GetConversationHistoryContext(ctx, params)
 		})
 		if err != nil {
-			return nil, err
+			return err
 		}
 
+		page := make([]Message, 0, len(history.Messages))
 		for i, msg := range history.Messages {
 			richMsg := Message{Message: msg}
 
 			// Fetch thread replies if any
 			if msg.ReplyCount > 0 {
 				if callback != nil {
-					callback(len(allMessages)+i, 0, fmt.Sprintf("Fetching thread (%d replies)...", msg.ReplyCount))
+					callback(fetched+i, 0, fmt.Sprintf("Fetching thread (%d replies)...", msg.ReplyCount))
 				}
-
-				// Fetch replies with retry
-				replies, err := withRetry(cfg, "GetConversationReplies", func() ([]slack.Message, error) {
-					msgs, _, _, err := client.GetConversationReplies(&slack.GetConversationRepliesParameters{
-						ChannelID: channelID,
-						Timestamp: msg.Timestamp,
-						Limit:     200,
-					})
-					return msgs, err
-				})
-
-				if err == nil && len(replies) > 1 {
-					richMsg.Replies = replies[1:]
+				richMsg.Replies, richMsg.ReplyWarning = fetchThread(ctx, client, channelID, msg, cfg, waiting)
+				if err := ctx.Err(); err != nil {
+					return err
 				}
 			}
-			allMessages = append(allMessages, richMsg)
+			page = append(page, richMsg)
+		}
+		fetched += len(page)
+
+		nextCursor := ""
+		if history.HasMore {
+			nextCursor = history.ResponseMetaData.NextCursor
+		}
+		if err := onPage(page, nextCursor); err != nil {
+			return err
 		}
 
 		if callback != nil {
-			callback(len(allMessages), 0, "Fetching history...")
+			callback(fetched, 0, "Fetching history...")
 		}
 
-		if !history.HasMore {
+		if nextCursor == "" {
 			break
 		}
-		params.Cursor = history.ResponseMetaData.NextCursor
+		params.Cursor = nextCursor
 
 		// Small delay between pagination to be nice to the API
-		time.Sleep(100 * time.Millisecond)
+		// (the shared limiter already paces calls when configured)
+		if cfg.Limiter == nil {
+			if err := sleep(ctx, 100*time.Millisecond); err != nil {
+				return err
+			}
+		}
 	}
 
-	return allMessages, nil
+	return nil
+}
+
+// FetchRepliesWithRetry fetches all replies of a thread, following the
+// pagination cursor. The parent message is not included. On error the
+// replies fetched so far are returned together with the error.
+func FetchRepliesWithRetry(ctx context.Context, client *slack.Client, channelID, threadTS string, cfg RetryConfig, progress ProgressCallback) ([]slack.Message, error) {
+	var replies []slack.Message
+	params := &slack.GetConversationRepliesParameters{
+		ChannelID: channelID,
+		Timestamp: threadTS,
+		Limit:     200,
+	}
+
+	for {
+		type page struct {
+			msgs       []slack.Message
+			hasMore    bool
+			nextCursor string
+		}
+		p, err := withRetry(ctx, cfg, "GetConversationReplies", progress, func() (page, error) {
+			msgs, hasMore, nextCursor, err := client.GetConversationRepliesContext(ctx, params)
+			return page{msgs, hasMore, nextCursor}, err
+		})
+		if err != nil {
+			return replies, err
+		}
+
+		for _, m := range p.msgs {
+			// Every page may start with the parent message itself, so we skip it
+			if m.Timestamp == threadTS {
+				continue
+			}
+			replies = append(replies, m)
+		}
+
+		if !p.hasMore || p.nextCursor == "" {
+			break
+		}
+		params.Cursor = p.nextCursor
+	}
+
+	return replies, nil
+}
+
+// fetchThread fetches the replies of a thread parent and returns a warning
+// if the thread could not be fetched completely.
+func fetchThread(ctx context.Context, client *slack.Client, channelID string, parent slack.Message, cfg RetryConfig, progress ProgressCallback) ([]slack.Message, string) {
+	replies, err := FetchRepliesWithRetry(ctx, client, channelID, parent.Timestamp, cfg, progress)
+	if err != nil {
+		return replies, fmt.Sprintf("fetched %d of %d replies: %v", len(replies), parent.ReplyCount, err)
+	}
+	if len(replies) < parent.ReplyCount {
+		return replies, fmt.Sprintf("fetched %d of %d replies", len(replies), parent.ReplyCount)
+	}
+	return replies, ""
 }
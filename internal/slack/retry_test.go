@@ -0,0 +1,229 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/slack/fakeslack"
+	"github.com/slack-go/slack"
+)
+
+const releaseThread = "1700000060.000100"
+
+// startFake serves the sample workspace and returns a client for it
+func startFake(t *testing.T) (*fakeslack.Server, *slack.Client) {
+	t.Helper()
+	ws := fakeslack.SampleWorkspace()
+	srv := fakeslack.New(ws)
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, slack.New(ws.Token, slack.OptionAPIURL(srv.APIURL()))
+}
+
+// testRetry retries without waiting long, whatever Retry-After says
+func testRetry(maxRetries int) RetryConfig {
+	return RetryConfig{MaxRetries: maxRetries, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+}
+
+func TestIsRateLimitError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		limited   bool
+		retryWait time.Duration
+	}{
+		{"nil", nil, false, 0},
+		{"other error", errors.New("channel_not_found"), false, 0},
+		{"rate limited error", &slack.RateLimitedError{RetryAfter: 3 * time.Second}, true, 3 * time.Second},
+		{"rate_limited message", errors.New("slack: rate_limited"), true, 30 * time.Second},
+		{"429 message", errors.New("HTTP 429 Too Many Requests"), true, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limited, wait := isRateLimitError(tt.err)
+			if limited != tt.limited || wait != tt.retryWait {
+				t.Errorf("isRateLimitError = %v, %s, want %v, %s", limited, wait, tt.limited, tt.retryWait)
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		limited    int // Calls answered with 429
+		maxRetries int
+		wantCalls  int
+		wantErr    string
+	}{
+		{"no rate limit", 0, 2, 1, ""},
+		{"retried", 2, 2, 3, ""},
+		{"too many retries", 3, 2, 3, "max retries (2) exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := startFake(t)
+			if tt.limited > 0 {
+				srv.RateLimit("auth.test", tt.limited, 0)
+			}
+			var waits []string
+			progress := func(_, _ int, status string) { waits = append(waits, status) }
+
+			resp, err := withRetry(context.Background(), testRetry(tt.maxRetries), "AuthTest", progress, func() (*slack.AuthTestResponse, error) {
+				return client.AuthTest()
+			})
+			if calls := srv.Calls("auth.test"); calls != tt.wantCalls {
+				t.Errorf("auth.test called %d times, want %d", calls, tt.wantCalls)
+			}
+			if len(waits) != min(tt.limited, tt.maxRetries) {
+				t.Errorf("reported %d waits, want %d: %q", len(waits), min(tt.limited, tt.maxRetries), waits)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.UserID != "U00000001" {
+				t.Errorf("user = %q, want U00000001", resp.UserID)
+			}
+		})
+	}
+}
+
+func TestWithRetryOtherError(t *testing.T) {
+	calls := 0
+	_, err := withRetry(context.Background(), testRetry(3), "Op", nil, func() (int, error) {
+		calls++
+		return 0, errors.New("channel_not_found")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("err = %v after %d calls, want the error after 1 call", err, calls)
+	}
+}
+
+func TestWithRetryCancelled(t *testing.T) {
+	srv, client := startFake(t)
+	srv.RateLimit("auth.test", 1, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cfg := RetryConfig{MaxRetries: 1, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+
+	_, err := withRetry(ctx, cfg, "AuthTest", func(_, _ int, _ string) { cancel() }, func() (*slack.AuthTestResponse, error) {
+		return client.AuthTest()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestFetchRepliesPagination(t *testing.T) {
+	tests := []struct {
+		pageSize  int
+		wantCalls int
+	}{
+		{200, 1},
+		{100, 2},
+		{40, 4}, // The parent and 150 replies
+	}
+	for _, tt := range tests {
+		srv, client := startFake(t)
+		srv.PageSize = tt.pageSize
+
+		replies, err := FetchRepliesWithRetry(context.Background(), client, "C00000001", releaseThread, testRetry(0), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(replies) != 150 || replies[0].Text != "reply 1" || replies[149].Text != "reply 150" {
+			t.Errorf("page size %d: got %d replies, want reply 1 to reply 150", tt.pageSize, len(replies))
+		}
+		if calls := srv.Calls("conversations.replies"); calls != tt.wantCalls {
+			t.Errorf("page size %d: conversations.replies called %d times, want %d", tt.pageSize, calls, tt.wantCalls)
+		}
+	}
+}
+
+func TestFetchThreadWarning(t *testing.T) {
+	srv, client := startFake(t)
+	srv.PageSize = 100
+	parent := slack.Message{}
+	parent.Timestamp, parent.ReplyCount = releaseThread, 150
+
+	// A rate-limited page is retried
+	srv.RateLimit("conversations.replies", 1, 0)
+	replies, warning := fetchThread(context.Background(), client, "C00000001", parent, testRetry(1), nil)
+	if len(replies) != 150 || warning != "" {
+		t.Errorf("got %d replies, warning %q, want all 150 replies", len(replies), warning)
+	}
+
+	parent.ReplyCount = 151
+	replies, warning = fetchThread(context.Background(), client, "C00000001", parent, testRetry(1), nil)
+	if len(replies) != 150 || warning != "fetched 150 of 151 replies" {
+		t.Errorf("got %d replies, warning %q, want a warning about the missing reply", len(replies), warning)
+	}
+
+	srv.RateLimit("conversations.replies", 2, 0)
+	replies, warning = fetchThread(context.Background(), client, "C00000001", parent, testRetry(1), nil)
+	if len(replies) != 0 || !strings.HasPrefix(warning, "fetched 0 of 151 replies: max retries") {
+		t.Errorf("got %d replies, warning %q, want the rate limit error", len(replies), warning)
+	}
+}
+
+func TestFetchHistoryPages(t *testing.T) {
+	srv, client := startFake(t)
+	srv.PageSize = 100
+
+	var cursors []string
+	var texts []string
+	err := FetchHistoryPages(context.Background(), client, "C00000002", testRetry(0), nil, "", "", "", 0, func(msgs []Message, next string) error {
+		cursors = append(cursors, next)
+		for _, m := range msgs {
+			texts = append(texts, m.Text)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(cursors, ",") != "100,200," {
+		t.Errorf("next cursors = %q, want 100, 200 and the end", cursors)
+	}
+	if len(texts) != 250 || texts[0] != "message 249" || texts[249] != "message 0" {
+		t.Errorf("got %d messages, want 250 newest first", len(texts))
+	}
+
+	// Resuming from a cursor fetches only the remaining pages
+	calls := srv.Calls("conversations.history")
+	n := 0
+	err = FetchHistoryPages(context.Background(), client, "C00000002", testRetry(0), nil, "", "", "200", 200, func(msgs []Message, _ string) error {
+		n += len(msgs)
+		return nil
+	})
+	if err != nil || n != 50 || srv.Calls("conversations.history")-calls != 1 {
+		t.Errorf("resumed fetch: err %v, %d messages, want 50 in one call", err, n)
+	}
+}
+
+func TestFetchHistoryPagesThreads(t *testing.T) {
+	srv, client := startFake(t)
+	srv.PageSize = 100
+
+	msgs, err := FetchHistoryWithRetryAndProgress(context.Background(), client, "C00000001", testRetry(0), nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range msgs {
+		if m.Timestamp == releaseThread {
+			if len(m.Replies) != 150 || m.ReplyWarning != "" {
+				t.Errorf("release thread has %d replies, warning %q, want 150", len(m.Replies), m.ReplyWarning)
+			}
+			return
+		}
+	}
+	t.Error("release thread not fetched")
+}
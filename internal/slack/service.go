@@ -1,8 +1,10 @@
 package slack
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strconv"
@@ -36,64 +38,75 @@ func ParseTimestamp(ts string) (time.Time, error) {
 // Message wraps slack.Message to include full reply history
 type Message struct {
 	slack.Message
-	Replies []slack.Message
+	Replies      []slack.Message
+	ReplyWarning string              // Set when the thread could not be fetched completely
+	Revisions    map[string]Revision // Changes seen across syncs, keyed by the ts of the message or one of its replies
+}
+
+// Revision records how an archived message changed in Slack after it was first fetched
+type Revision struct {
+	Previous []slack.Message // Earlier versions, oldest first
+	Deleted  bool            // The message was deleted in Slack
+}
+
+// RevisionOf returns the recorded changes of the message or reply with the given ts
+func (m Message) RevisionOf(ts string) Revision {
+	return m.Revisions[ts]
 }
 
 // CachedChannel stores channel info for local caching
 type CachedChannel struct {
-	ID         string `json:"id"`
-	Name       string `json:"name"`
-	IsArchived bool   `json:"is_archived"`
-	IsPrivate  bool   `json:"is_private"`
-	IsChannel  bool   `json:"is_channel"`
-	IsGroup    bool   `json:"is_group"`
-	IsIM       bool   `json:"is_im"`
-	IsMpIM     bool   `json:"is_mpim"`
-	IsMember   bool   `json:"is_member"`
-	NumMembers int    `json:"num_members"`
-	Topic      string `json:"topic"`
-	Purpose    string `json:"purpose"`
-	Created    int64  `json:"created"`
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	IsArchived bool     `json:"is_archived"`
+	IsPrivate  bool     `json:"is_private"`
+	IsChannel  bool     `json:"is_channel"`
+	IsGroup    bool     `json:"is_group"`
+	IsIM       bool     `json:"is_im"`
+	IsMpIM     bool     `json:"is_mpim"`
+	User       string   `json:"user,omitempty"`    // The other participant of a DM
+	Members    []string `json:"members,omitempty"` // Participants, when known (e.g. from an export archive)
+	IsMember   bool     `json:"is_member"`
+	NumMembers int      `json:"num_members"`
+	Topic      string   `json:"topic"`
+	Purpose    string   `json:"purpose"`
+	Created    int64    `json:"created"`
+	Imported   bool     `json:"imported,omitempty"` // Only known from an imported export archive
 }
 
-func FetchChannels(client *slack.Client, forceRefresh bool) ([]slack.Channel, error) {
-	cacheFile := "channels.json"
-
+// FetchChannels returns the cached channel list, fetching it from the API
+// when there is no cache or a refresh is forced. Progress is written to out.
+func FetchChannels(client *slack.Client, forceRefresh bool, out io.Writer) ([]slack.Channel, error) {
 	// 1. Try to load from cache
 	if !forceRefresh {
-		if _, err := os.Stat(cacheFile); err == nil {
-			data, err := os.ReadFile(cacheFile)
-			if err == nil {
-				var cachedChannels []CachedChannel
-				if err := json.Unmarshal(data, &cachedChannels); err == nil {
-					fmt.Printf("Loaded %d channels from cache (channels.json).\n", len(cachedChannels))
-					// Convert CachedChannel to slack.Channel
-					channels := make([]slack.Channel, len(cachedChannels))
-					for i, cc := range cachedChannels {
-						ch := slack.Channel{}
-						ch.ID = cc.ID
-						ch.Name = cc.Name
-						ch.IsArchived = cc.IsArchived
-						ch.IsPrivate = cc.IsPrivate
-						ch.IsChannel = cc.IsChannel
-						ch.IsGroup = cc.IsGroup
-						ch.IsIM = cc.IsIM
-						ch.IsMpIM = cc.IsMpIM
-						ch.IsMember = cc.IsMember
-						ch.NumMembers = cc.NumMembers
-						ch.Topic = slack.Topic{Value: cc.Topic}
-						ch.Purpose = slack.Purpose{Value: cc.Purpose}
-						ch.Created = slack.JSONTime(cc.Created)
-						channels[i] = ch
-					}
-					return channels, nil
-				}
+		if cachedChannels, err := LoadCachedChannels(); err == nil && !missingDMUsers(cachedChannels) {
+			fmt.Fprintf(out, "Loaded %d channels from cache (channels.json).\n", len(cachedChannels))
+			// Convert CachedChannel to slack.Channel
+			channels := make([]slack.Channel, len(cachedChannels))
+			for i, cc := range cachedChannels {
+				ch := slack.Channel{}
+				ch.ID = cc.ID
+				ch.Name = cc.Name
+				ch.IsArchived = cc.IsArchived
+				ch.IsPrivate = cc.IsPrivate
+				ch.IsChannel = cc.IsChannel
+				ch.IsGroup = cc.IsGroup
+				ch.IsIM = cc.IsIM
+				ch.IsMpIM = cc.IsMpIM
+				ch.User = cc.User
+				ch.IsMember = cc.IsMember
+				ch.NumMembers = cc.NumMembers
+				ch.Topic = slack.Topic{Value: cc.Topic}
+				ch.Purpose = slack.Purpose{Value: cc.Purpose}
+				ch.Created = slack.JSONTime(cc.Created)
+				channels[i] = ch
 			}
+			return channels, nil
 		}
 	}
 
 	// 2. Fetch from API (with pagination)
-	fmt.Println("Fetching channel list from Slack API...")
+	fmt.Fprintln(out, "Fetching channel list from Slack API...")
 	params := &slack.GetConversationsParameters{
 		Types: []string{"public_channel", "private_channel", "mpim", "im"},
 		Limit: 1000,
@@ -111,12 +124,13 @@ func FetchChannels(client *slack.Client, forceRefresh bool) ([]slack.Channel, er
 			break
 		}
 		params.Cursor = nextCursor
-		fmt.Printf("  ...fetched %d channels so far\n", len(allChannels))
+		fmt.Fprintf(out, "  ...fetched %d channels so far\n", len(allChannels))
 	}
-	fmt.Printf("  -> Fetched %d channels total.\n", len(allChannels))
+	fmt.Fprintf(out, "  -> Fetched %d channels total.\n", len(allChannels))
 
 	// 3. Save to cache
 	cachedChannels := make([]CachedChannel, len(allChannels))
+	listed := make(map[string]bool, len(allChannels))
 	for i, ch := range allChannels {
 		cachedChannels[i] = CachedChannel{
 			ID:         ch.ID,
@@ -127,17 +141,26 @@ func FetchChannels(client *slack.Client, forceRefresh bool) ([]slack.Channel, er
 			IsGroup:    ch.IsGroup,
 			IsIM:       ch.IsIM,
 			IsMpIM:     ch.IsMpIM,
+			User:       ch.User,
 			IsMember:   ch.IsMember,
 			NumMembers: ch.NumMembers,
 			Topic:      ch.Topic.Value,
 			Purpose:    ch.Purpose.Value,
 			Created:    int64(ch.Created),
 		}
+		listed[ch.ID] = true
 	}
-	data, err := json.MarshalIndent(cachedChannels, "", "  ")
-	if err == nil {
-		_ = os.WriteFile(cacheFile, data, 0644)
-		fmt.Println("Saved channel list to cache (channels.json).")
+	// Conversations known only from an imported archive are not in the
+	// API's list, but exports still need their names and types
+	if old, err := LoadCachedChannels(); err == nil {
+		for _, cc := range old {
+			if cc.Imported && !listed[cc.ID] {
+				cachedChannels = append(cachedChannels, cc)
+			}
+		}
+	}
+	if err := SaveCachedChannels(cachedChannels); err == nil {
+		fmt.Fprintln(out, "Saved channel list to cache (channels.json).")
 	}
 
 	// Sort channels by name
@@ -148,56 +171,27 @@ func FetchChannels(client *slack.Client, forceRefresh bool) ([]slack.Channel, er
 	return allChannels, nil
 }
 
-func FetchUsers(client *slack.Client, forceRefresh bool) (map[string]string, error) {
-	cacheFile := "users.json"
-	userMap := make(map[string]string)
-	cacheExists := false
-
-	// 1. Try to load from cache
-	if _, err := os.Stat(cacheFile); err == nil {
-		data, err := os.ReadFile(cacheFile)
-		if err == nil {
-			if err := json.Unmarshal(data, &userMap); err == nil {
-				fmt.Println("Loaded user list from cache (users.json).")
-				cacheExists = true
-			}
-		}
-	}
-
-	if cacheExists && !forceRefresh {
-		return userMap, nil
-	}
-
-	// 2. Fetch from API
-	fmt.Println("Fetching user list from Slack API...")
-
-	// slack-go GetUsers fetches all users (handles pagination internally)
-	allUsers, err := client.GetUsers()
+// LoadCachedChannels loads the channel list from the local cache (channels.json)
+// without calling the Slack API
+func LoadCachedChannels() ([]CachedChannel, error) {
+	data, err := os.ReadFile("channels.json")
 	if err != nil {
 		return nil, err
 	}
-	fmt.Printf("  -> Fetched %d users total.\n", len(allUsers))
-
-	for _, u := range allUsers {
-		// Safe Refresh: Only overwrite if API returns a non-empty name
-		if u.RealName != "" {
-			userMap[u.ID] = u.RealName
-		} else {
-			// If RealName is empty, try Name (handle) if we don't have a name yet
-			if _, exists := userMap[u.ID]; !exists && u.Name != "" {
-				userMap[u.ID] = u.Name
-			}
-		}
+	var cachedChannels []CachedChannel
+	if err := json.Unmarshal(data, &cachedChannels); err != nil {
+		return nil, fmt.Errorf("failed to parse channels.json: %w", err)
 	}
+	return cachedChannels, nil
+}
 
-	// 3. Save to cache
-	data, err := json.MarshalIndent(userMap, "", "  ")
-	if err == nil {
-		_ = os.WriteFile(cacheFile, data, 0644)
-		fmt.Println("Saved user list to cache (users.json).")
+// SaveCachedChannels writes the channel list to the local cache (channels.json)
+func SaveCachedChannels(channels []CachedChannel) error {
+	data, err := json.MarshalIndent(channels, "", "  ")
+	if err != nil {
+		return err
 	}
-
-	return userMap, nil
+	return os.WriteFile("channels.json", data, 0644)
 }
 
 func FetchHistory(client *slack.Client, channelID string) ([]Message, error) {
@@ -220,16 +214,9 @@ func FetchHistory(client *slack.Client, channelID string) ([]Message, error) {
 			// Fetch thread replies if any
 			if msg.ReplyCount > 0 {
 				fmt.Printf("    Fetching %d replies for thread %s...\n", msg.ReplyCount, msg.Timestamp)
-				replies, _, _, err := client.GetConversationReplies(&slack.GetConversationRepliesParameters{
-					ChannelID: channelID,
-					Timestamp: msg.Timestamp,
-					Limit:     1000, // Assuming threads aren't huge, but might need pagination too
-				})
-				if err == nil && len(replies) > 1 {
-					// replies[0] is the parent message itself, so we skip it
-					richMsg.Replies = replies[1:]
-				} else if err != nil {
-					fmt.Printf("    Warning: Failed to fetch replies: %v\n", err)
+				richMsg.Replies, richMsg.ReplyWarning = fetchThread(context.Background(), client, channelID, msg, RetryConfig{}, nil)
+				if richMsg.ReplyWarning != "" {
+					fmt.Printf("    Warning: Incomplete thread %s: %s\n", msg.Timestamp, richMsg.ReplyWarning)
 				}
 			}
 			allMessages = append(allMessages, richMsg)
@@ -272,14 +259,7 @@ func FetchHistoryWithProgress(client *slack.Client, channelID string, callback P
 				if callback != nil {
 					callback(len(allMessages)+i, 0, fmt.Sprintf("Fetching thread (%d replies)...", msg.ReplyCount))
 				}
-				replies, _, _, err := client.GetConversationReplies(&slack.GetConversationRepliesParameters{
-					ChannelID: channelID,
-					Timestamp: msg.Timestamp,
-					Limit:     1000,
-				})
-				if err == nil && len(replies) > 1 {
-					richMsg.Replies = replies[1:]
-				}
+				richMsg.Replies, richMsg.ReplyWarning = fetchThread(context.Background(), client, channelID, msg, RetryConfig{}, nil)
 			}
 			allMessages = append(allMessages, richMsg)
 		}
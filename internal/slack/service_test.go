@@ -0,0 +1,49 @@
+package slack
+
+import (
+	"io"
+	"testing"
+
+	"github.com/chanseok/slackExtract/internal/slack/fakeslack"
+	"github.com/slack-go/slack"
+)
+
+func TestFetchChannelsKeepsImported(t *testing.T) {
+	t.Chdir(t.TempDir())
+	ws := fakeslack.SampleWorkspace()
+	srv := fakeslack.New(ws)
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	err := SaveCachedChannels([]CachedChannel{
+		{ID: "C00000001", Name: "general-imported", Imported: true},
+		{ID: "D99999999", Name: "dm-archived", IsIM: true, Imported: true},
+		{ID: "C99999999", Name: "deleted"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := slack.New(ws.Token, slack.OptionAPIURL(srv.APIURL()))
+	if _, err := FetchChannels(client, true, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := LoadCachedChannels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]CachedChannel)
+	for _, ch := range cached {
+		byID[ch.ID] = ch
+	}
+	if ch := byID["C00000001"]; ch.Name != "general" || ch.Imported {
+		t.Errorf("C00000001 = %+v, want the listed channel", ch)
+	}
+	if ch, ok := byID["D99999999"]; !ok || !ch.Imported {
+		t.Errorf("imported DM was dropped")
+	}
+	if _, ok := byID["C99999999"]; ok {
+		t.Errorf("unlisted synced channel was kept")
+	}
+}
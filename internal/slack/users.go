@@ -0,0 +1,297 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/slack-go/slack"
+)
+
+// usersCacheFile is the local cache of the user directory
+const usersCacheFile = "users.json"
+
+// usersCacheVersion is the schema version of users.json. Version 0 is the
+// old flat map of user ID → real name.
+const usersCacheVersion = 1
+
+// NameStyle selects which of a user's names is shown in exports
+type NameStyle string
+
+const (
+	NameReal    NameStyle = "real"    // Full name, e.g. "Alice Kim"
+	NameDisplay NameStyle = "display" // Display name set in the profile, e.g. "alice.k"
+	NameHandle  NameStyle = "handle"  // Account name, e.g. "alice"
+)
+
+// UserDirectory keeps the full profile of every user and bot seen in the
+// workspace. It is safe for concurrent use.
+type UserDirectory struct {
+	Version int                   `json:"version"`
+	Users   map[string]slack.User `json:"users"`
+	Bots    map[string]slack.Bot  `json:"bots,omitempty"`
+
+	mu sync.Mutex
+}
+
+// NewUserDirectory returns an empty directory
+func NewUserDirectory() *UserDirectory {
+	return &UserDirectory{
+		Version: usersCacheVersion,
+		Users:   make(map[string]slack.User),
+		Bots:    make(map[string]slack.Bot),
+	}
+}
+
+// Add stores or replaces users. A refresh never replaces a known name with
+// an empty one.
+func (d *UserDirectory) Add(users ...slack.User) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, u := range users {
+		if old, ok := d.Users[u.ID]; ok && u.RealName == "" && u.Name == "" {
+			u.RealName, u.Name = old.RealName, old.Name
+		}
+		d.Users[u.ID] = u
+	}
+}
+
+// Lookup returns the profile of a user
+func (d *UserDirectory) Lookup(id string) (slack.User, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	u, ok := d.Users[id]
+	return u, ok
+}
+
+// LookupBot returns the profile of a bot
+func (d *UserDirectory) LookupBot(id string) (slack.Bot, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	b, ok := d.Bots[id]
+	return b, ok
+}
+
+// Name returns how a user or bot ID is shown, or "" if it is unknown
+func (d *UserDirectory) Name(id string, style NameStyle) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.name(id, style)
+}
+
+func (d *UserDirectory) name(id string, style NameStyle) string {
+	if u, ok := d.Users[id]; ok {
+		return userName(u, style)
+	}
+	if b, ok := d.Bots[id]; ok {
+		return b.Name
+	}
+	return ""
+}
+
+// Avatar returns the URL of a user's or bot's profile image, or "" if none is cached
+func (d *UserDirectory) Avatar(id string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if u, ok := d.Users[id]; ok {
+		if u.Profile.Image72 != "" {
+			return u.Profile.Image72
+		}
+		return u.Profile.Image48
+	}
+	if b, ok := d.Bots[id]; ok {
+		if b.Icons.Image72 != "" {
+			return b.Icons.Image72
+		}
+		return b.Icons.Image48
+	}
+	return ""
+}
+
+// userName picks a user's name in the given style, falling back to the
+// other names when that one is not set
+func userName(u slack.User, style NameStyle) string {
+	realName := u.RealName
+	if realName == "" {
+		realName = u.Profile.RealName
+	}
+	candidates := []string{realName, u.Profile.DisplayName, u.Name}
+	switch style {
+	case NameDisplay:
+		candidates = []string{u.Profile.DisplayName, realName, u.Name}
+	case NameHandle:
+		candidates = []string{u.Name, u.Profile.DisplayName, realName}
+	}
+	for _, name := range candidates {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// Names returns user and bot ID → name for rendering. Deactivated accounts
+// are suffixed with "(deactivated)" when markDeactivated is set.
+func (d *UserDirectory) Names(style NameStyle, markDeactivated bool) map[string]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names := make(map[string]string, len(d.Users)+len(d.Bots))
+	for id, b := range d.Bots {
+		if b.Name != "" {
+			names[id] = b.Name
+		}
+	}
+	for id, u := range d.Users {
+		name := userName(u, style)
+		if name == "" {
+			continue
+		}
+		if markDeactivated && u.Deleted {
+			name += " (deactivated)"
+		}
+		names[id] = name
+	}
+	return names
+}
+
+// ResolveBots looks up unknown bot IDs with bots.info and saves the result
+// to the cache. A bot that cannot be looked up is skipped. It returns the
+// number of bots added and the first lookup error.
+func (d *UserDirectory) ResolveBots(ctx context.Context, client *slack.Client, cfg RetryConfig, botIDs []string) (int, error) {
+	d.mu.Lock()
+	var unknown []string
+	seen := make(map[string]bool)
+	for _, id := range botIDs {
+		if _, ok := d.Bots[id]; !ok && id != "" && !seen[id] {
+			unknown = append(unknown, id)
+			seen[id] = true
+		}
+	}
+	d.mu.Unlock()
+
+	added := 0
+	var firstErr error
+	for _, id := range unknown {
+		bot, err := withRetry(ctx, cfg, "GetBotInfo", nil, func() (*slack.Bot, error) {
+			return client.GetBotInfoContext(ctx, slack.GetBotInfoParameters{Bot: id})
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return added, errors.Join(ctx.Err(), d.saveIf(added > 0))
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to look up bot %s: %w", id, err)
+			}
+			continue
+		}
+		d.mu.Lock()
+		d.Bots[id] = *bot
+		d.mu.Unlock()
+		added++
+	}
+	return added, errors.Join(firstErr, d.saveIf(added > 0))
+}
+
+// saveIf saves the directory when changed is set
+func (d *UserDirectory) saveIf(changed bool) error {
+	if !changed {
+		return nil
+	}
+	return d.Save()
+}
+
+// Save writes the directory to the local cache (users.json)
+func (d *UserDirectory) Save() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.Version = usersCacheVersion
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(usersCacheFile, data, 0644)
+}
+
+// LoadUserDirectory loads the user directory from the local cache
+// (users.json) without calling the Slack API. A cache in the old flat format
+// is converted, keeping only the names it contains.
+func LoadUserDirectory() (*UserDirectory, error) {
+	data, err := os.ReadFile(usersCacheFile)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := NewUserDirectory()
+	var probe struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &probe); err == nil && probe.Version > 0 {
+		if probe.Version > usersCacheVersion {
+			return nil, fmt.Errorf("users.json has unsupported version %d", probe.Version)
+		}
+		if err := json.Unmarshal(data, dir); err != nil {
+			return nil, fmt.Errorf("failed to parse users.json: %w", err)
+		}
+		if dir.Users == nil {
+			dir.Users = make(map[string]slack.User)
+		}
+		if dir.Bots == nil {
+			dir.Bots = make(map[string]slack.Bot)
+		}
+		return dir, nil
+	}
+
+	legacy := make(map[string]string)
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to parse users.json: %w", err)
+	}
+	for id, name := range legacy {
+		dir.Users[id] = slack.User{ID: id, RealName: name}
+	}
+	dir.Version = 0
+	return dir, nil
+}
+
+// FetchUserDirectory returns the cached user directory, fetching it from
+// the API when there is no cache, the cache uses an old schema or a refresh
+// is forced. When the fetch fails but a cache was loaded, the cache is
+// returned with a warning. Progress is written to out.
+func FetchUserDirectory(ctx context.Context, client *slack.Client, cfg RetryConfig, forceRefresh bool, out io.Writer, progress ProgressCallback) (*UserDirectory, error) {
+	dir, err := LoadUserDirectory()
+	cached := err == nil
+	if cached {
+		fmt.Fprintln(out, "Loaded user list from cache (users.json).")
+		if !forceRefresh && dir.Version == usersCacheVersion {
+			return dir, nil
+		}
+	} else {
+		dir = NewUserDirectory()
+	}
+
+	fmt.Fprintln(out, "Fetching user list from Slack API...")
+
+	// slack-go GetUsers fetches all users (handles pagination internally)
+	allUsers, err := withRetry(ctx, cfg, "GetUsers", progress, func() ([]slack.User, error) {
+		return client.GetUsersContext(ctx)
+	})
+	if err != nil {
+		if cached {
+			fmt.Fprintf(out, "Warning: Could not refresh users, using cache: %v\n", err)
+			return dir, nil
+		}
+		return nil, err
+	}
+	fmt.Fprintf(out, "  -> Fetched %d users total.\n", len(allUsers))
+	dir.Add(allUsers...)
+
+	if err := dir.Save(); err == nil {
+		fmt.Fprintln(out, "Saved user list to cache (users.json).")
+	}
+
+	return dir, nil
+}
@@ -0,0 +1,537 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+// RawDirName is the directory under the export root holding the raw message store
+const RawDirName = ".raw"
+
+// Record is one line of a channel's raw store. Records are only ever
+// appended; a later record for the same message timestamp supersedes
+// (and is merged with) an earlier one, and the superseded versions are
+// kept as the message's revision history.
+//
+// Deletion records only carry the message timestamp: Deleted marks the
+// message itself, DeletedReplies the listed replies of its thread.
+type Record struct {
+	FetchedAt      time.Time         `json:"fetched_at"`
+	Message        slackgo.Message   `json:"message"`
+	Replies        []slackgo.Message `json:"replies,omitempty"`
+	ReplyWarning   string            `json:"reply_warning,omitempty"`
+	Deleted        bool              `json:"deleted,omitempty"`
+	DeletedReplies []string          `json:"deleted_replies,omitempty"`
+}
+
+// Store keeps the raw Slack messages of every channel as append-only JSONL
+// files, one segment per month of message time (UTC), under
+// {exportRoot}/.raw/{channelID}/{YYYY-MM}.jsonl. A message and its thread
+// replies always live in the segment of the message, so a segment can be
+// loaded on its own and memory use does not grow with the channel.
+type Store struct {
+	dir string
+}
+
+// New creates a store rooted at the given export directory
+func New(exportRoot string) *Store {
+	return NewAt(filepath.Join(exportRoot, RawDirName))
+}
+
+// NewAt creates a store that keeps its channels directly under dir
+func NewAt(dir string) *Store {
+	return &Store{
+		dir: dir,
+	}
+}
+
+// Path returns the segment directory of a channel
+func (s *Store) Path(channelID string) string {
+	return filepath.Join(s.dir, channelID)
+}
+
+// legacyPath returns the single JSONL file channels were stored in before
+// the store was split into monthly segments
+func (s *Store) legacyPath(channelID string) string {
+	return filepath.Join(s.dir, channelID+".jsonl")
+}
+
+func (s *Store) segmentPath(channelID, month string) string {
+	return filepath.Join(s.Path(channelID), month+".jsonl")
+}
+
+// MonthOf returns the segment a message timestamp belongs to, e.g. "2025-07"
+func MonthOf(ts string) string {
+	t, err := slack.ParseTimestamp(ts)
+	if err != nil {
+		return "0000-00"
+	}
+	return t.UTC().Format(monthLayout)
+}
+
+// monthLayout is the name of a segment
+const monthLayout = "2006-01"
+
+// Exists reports whether a channel has stored messages
+func (s *Store) Exists(channelID string) bool {
+	if info, err := os.Stat(s.legacyPath(channelID)); err == nil && info.Size() > 0 {
+		return true
+	}
+	months, err := s.Months(channelID)
+	return err == nil && len(months) > 0
+}
+
+// Reset removes all stored messages of a channel
+func (s *Store) Reset(channelID string) error {
+	if err := os.RemoveAll(s.Path(channelID)); err != nil {
+		return fmt.Errorf("failed to reset raw store: %w", err)
+	}
+	err := os.Remove(s.legacyPath(channelID))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to reset raw store: %w", err)
+	}
+	return nil
+}
+
+// Months returns the segments of a channel from oldest to newest
+func (s *Store) Months(channelID string) ([]string, error) {
+	if err := s.migrate(channelID); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(s.Path(channelID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list raw store: %w", err)
+	}
+	var months []string
+	for _, e := range entries {
+		if month, ok := strings.CutSuffix(e.Name(), ".jsonl"); ok && !e.IsDir() {
+			months = append(months, month)
+		}
+	}
+	sort.Strings(months)
+	return months, nil
+}
+
+// Append adds messages (with their replies) to a channel's store
+func (s *Store) Append(channelID string, msgs []slack.Message) error {
+	records := make([]Record, 0, len(msgs))
+	for _, msg := range msgs {
+		records = append(records, Record{
+			Message:      msg.Message,
+			Replies:      msg.Replies,
+			ReplyWarning: msg.ReplyWarning,
+		})
+	}
+	return s.write(channelID, records)
+}
+
+// MarkDeleted records that messages (by ts) and thread replies (by parent
+// ts → reply ts) were deleted in Slack. Their stored content is kept.
+func (s *Store) MarkDeleted(channelID string, messages []string, replies map[string][]string) error {
+	var records []Record
+	for _, ts := range messages {
+		record := Record{Deleted: true}
+		record.Message.Timestamp = ts
+		records = append(records, record)
+	}
+	for parentTS, replyTS := range replies {
+		record := Record{DeletedReplies: replyTS}
+		record.Message.Timestamp = parentTS
+		records = append(records, record)
+	}
+	return s.write(channelID, records)
+}
+
+// write appends records to the segments of their messages
+func (s *Store) write(channelID string, records []Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := s.migrate(channelID); err != nil {
+		return err
+	}
+
+	byMonth := make(map[string][]Record)
+	for _, record := range records {
+		month := MonthOf(record.Message.Timestamp)
+		byMonth[month] = append(byMonth[month], record)
+	}
+
+	now := time.Now()
+	for month, records := range byMonth {
+		for i := range records {
+			records[i].FetchedAt = now
+		}
+		if err := s.appendSegment(s.segmentPath(channelID, month), records); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendSegment appends records to one segment file
+func (s *Store) appendSegment(path string, records []Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create raw store directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open raw store: %w", err)
+	}
+	defer file.Close()
+
+	// A write interrupted by a crash leaves a partial last line; new records
+	// must not be appended to it
+	if err := trimTornTail(file); err != nil {
+		return fmt.Errorf("failed to repair raw store %s: %w", path, err)
+	}
+
+	encoder := json.NewEncoder(file)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write raw store: %w", err)
+		}
+	}
+
+	return file.Sync()
+}
+
+// trimTornTail truncates a segment after its last complete line and leaves
+// the file offset at the new end
+func trimTornTail(file *os.File) error {
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil || size == 0 {
+		return err
+	}
+
+	// Search backwards for the last newline, one block at a time
+	buf := make([]byte, 4096)
+	end := size
+	for end > 0 {
+		start := end - int64(len(buf))
+		if start < 0 {
+			start = 0
+		}
+		block := buf[:end-start]
+		if _, err := file.ReadAt(block, start); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(block, '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			break
+		}
+		end = start
+	}
+	if end == size {
+		return nil
+	}
+	if err := file.Truncate(end); err != nil {
+		return err
+	}
+	_, err = file.Seek(end, io.SeekStart)
+	return err
+}
+
+// migrate splits a channel stored in the old single-file layout into
+// monthly segments. The segments are built next to the channel directory
+// and moved into place at once, so an interrupted migration starts over.
+func (s *Store) migrate(channelID string) error {
+	legacy := s.legacyPath(channelID)
+	file, err := os.Open(legacy)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open raw store: %w", err)
+	}
+	defer file.Close()
+
+	tmpDir := s.Path(channelID) + ".migrating"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return fmt.Errorf("failed to migrate raw store: %w", err)
+	}
+
+	// Records are copied in order, so every segment replays like the old file
+	segments := make(map[string]*os.File)
+	defer func() {
+		for _, f := range segments {
+			f.Close()
+		}
+	}()
+	err = eachRecord(file, func(record []byte) error {
+		var key struct {
+			Message struct {
+				Timestamp string `json:"ts"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal(record, &key); err != nil {
+			return fmt.Errorf("failed to read raw store %s: %w", legacy, err)
+		}
+
+		month := MonthOf(key.Message.Timestamp)
+		seg, ok := segments[month]
+		if !ok {
+			if err := os.MkdirAll(tmpDir, 0755); err != nil {
+				return fmt.Errorf("failed to migrate raw store: %w", err)
+			}
+			var err error
+			if seg, err = os.Create(filepath.Join(tmpDir, month+".jsonl")); err != nil {
+				return fmt.Errorf("failed to migrate raw store: %w", err)
+			}
+			segments[month] = seg
+		}
+		if _, err := seg.Write(append(record, '\n')); err != nil {
+			return fmt.Errorf("failed to migrate raw store: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for month, seg := range segments {
+		err := seg.Sync()
+		if cerr := seg.Close(); err == nil {
+			err = cerr
+		}
+		delete(segments, month)
+		if err != nil {
+			return fmt.Errorf("failed to migrate raw store: %w", err)
+		}
+	}
+
+	if _, err := os.Stat(tmpDir); err == nil {
+		if err := os.RemoveAll(s.Path(channelID)); err != nil {
+			return fmt.Errorf("failed to migrate raw store: %w", err)
+		}
+		if err := os.Rename(tmpDir, s.Path(channelID)); err != nil {
+			return fmt.Errorf("failed to migrate raw store: %w", err)
+		}
+	}
+	file.Close()
+	return os.Remove(legacy)
+}
+
+// Load returns the current state of every stored message of a channel,
+// sorted from oldest to newest. It returns no messages if nothing is stored.
+// Use Each to go through a large channel without loading all of it.
+func (s *Store) Load(channelID string) ([]slack.Message, error) {
+	var msgs []slack.Message
+	err := s.Each(channelID, func(msg slack.Message) error {
+		msgs = append(msgs, msg)
+		return nil
+	})
+	return msgs, err
+}
+
+// Each calls fn with the current state of every stored message of a
+// channel, from oldest to newest, loading one segment at a time. An error
+// returned by fn stops the iteration and is returned.
+func (s *Store) Each(channelID string, fn func(slack.Message) error) error {
+	months, err := s.Months(channelID)
+	if err != nil {
+		return err
+	}
+	for _, month := range months {
+		msgs, err := s.LoadMonth(channelID, month)
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			if err := fn(msg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Last returns the newest stored message of a channel, or nil if nothing
+// is stored
+func (s *Store) Last(channelID string) (*slack.Message, error) {
+	months, err := s.Months(channelID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(months) - 1; i >= 0; i-- {
+		msgs, err := s.LoadMonth(channelID, months[i])
+		if err != nil {
+			return nil, err
+		}
+		if len(msgs) > 0 {
+			return &msgs[len(msgs)-1], nil
+		}
+	}
+	return nil, nil
+}
+
+// LoadMonth returns the current state of the messages in one segment of a
+// channel, sorted from oldest to newest
+func (s *Store) LoadMonth(channelID, month string) ([]slack.Message, error) {
+	path := s.segmentPath(channelID, month)
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open raw store: %w", err)
+	}
+	defer file.Close()
+
+	byTS := make(map[string]*slack.Message)
+	err = eachRecord(file, func(line []byte) error {
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("failed to read raw store %s: %w", path, err)
+		}
+
+		ts := record.Message.Timestamp
+		prev, ok := byTS[ts]
+		switch {
+		case record.Deleted || len(record.DeletedReplies) > 0:
+			if ok {
+				applyDeletions(prev, record)
+			}
+		case ok:
+			// A thread parent deleted in Slack comes back as a tombstone;
+			// keep the archived content and only mark it deleted
+			if record.Message.SubType == "tombstone" {
+				markDeleted(prev, ts)
+			} else {
+				if Changed(prev.Message, record.Message) {
+					addRevision(prev, ts, prev.Message)
+				}
+				prev.Message = record.Message
+			}
+			prev.Replies = mergeReplies(prev, record.Replies)
+			prev.ReplyWarning = record.ReplyWarning
+		default:
+			byTS[ts] = &slack.Message{
+				Message:      record.Message,
+				Replies:      record.Replies,
+				ReplyWarning: record.ReplyWarning,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]slack.Message, 0, len(byTS))
+	for _, msg := range byTS {
+		msgs = append(msgs, *msg)
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		return msgs[i].Timestamp < msgs[j].Timestamp
+	})
+	return msgs, nil
+}
+
+// eachRecord calls fn with every record line of a segment. Lines that are
+// not valid JSON are skipped: they are writes torn by a crash, at the end of
+// the file or, in stores written by earlier versions, somewhere in the
+// middle with the next record appended to them.
+func eachRecord(r io.Reader, fn func(line []byte) error) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read raw store: %w", err)
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 && json.Valid(line) {
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// Changed reports whether a newer fetch of a message is an edit of the
+// stored one: its edited.ts or its text differs
+func Changed(older, newer slackgo.Message) bool {
+	return editedTS(older) != editedTS(newer) || older.Text != newer.Text
+}
+
+func editedTS(msg slackgo.Message) string {
+	if msg.Edited == nil {
+		return ""
+	}
+	return msg.Edited.Timestamp
+}
+
+// addRevision records an earlier version of the message or reply with the given ts
+func addRevision(msg *slack.Message, ts string, previous slackgo.Message) {
+	if msg.Revisions == nil {
+		msg.Revisions = make(map[string]slack.Revision)
+	}
+	rev := msg.Revisions[ts]
+	rev.Previous = append(rev.Previous, previous)
+	msg.Revisions[ts] = rev
+}
+
+func markDeleted(msg *slack.Message, ts string) {
+	if msg.Revisions == nil {
+		msg.Revisions = make(map[string]slack.Revision)
+	}
+	rev := msg.Revisions[ts]
+	rev.Deleted = true
+	msg.Revisions[ts] = rev
+}
+
+// applyDeletions marks the message or replies named by a deletion record
+func applyDeletions(msg *slack.Message, record Record) {
+	if record.Deleted {
+		markDeleted(msg, msg.Timestamp)
+	}
+	for _, ts := range record.DeletedReplies {
+		markDeleted(msg, ts)
+	}
+}
+
+// mergeReplies combines the stored thread of msg with a newer fetch. Newer
+// replies replace older ones with the same timestamp (keeping edits as
+// revisions), and replies missing from a newer (possibly incomplete) fetch
+// are kept.
+func mergeReplies(msg *slack.Message, newer []slackgo.Message) []slackgo.Message {
+	if len(msg.Replies) == 0 {
+		return newer
+	}
+	byTS := make(map[string]slackgo.Message, len(msg.Replies)+len(newer))
+	for _, r := range msg.Replies {
+		byTS[r.Timestamp] = r
+	}
+	for _, r := range newer {
+		if old, ok := byTS[r.Timestamp]; ok && Changed(old, r) {
+			addRevision(msg, r.Timestamp, old)
+		}
+		byTS[r.Timestamp] = r
+	}
+
+	merged := make([]slackgo.Message, 0, len(byTS))
+	for _, r := range byTS {
+		merged = append(merged, r)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Timestamp < merged[j].Timestamp
+	})
+	return merged
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"os"
+	"testing"
+
+	"github.com/chanseok/slackExtract/internal/slack"
+	slackgo "github.com/slack-go/slack"
+)
+
+func message(ts, text string) slack.Message {
+	return slack.Message{Message: slackgo.Message{Msg: slackgo.Msg{Timestamp: ts, Text: text}}}
+}
+
+func TestAppendAfterTornWrite(t *testing.T) {
+	s := NewAt(t.TempDir())
+	if err := s.Append("C1", []slack.Message{message("1700000000.000100", "first")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash in the middle of writing a record
+	path := s.segmentPath("C1", "2023-11")
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"fetched_at":"2023-11-14T22:13:20Z","message":{"ts":"17000`); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	// The torn record is tolerated while it is the last line...
+	msgs, err := s.LoadMonth("C1", "2023-11")
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("LoadMonth with torn tail = %d messages, %v; want 1, nil", len(msgs), err)
+	}
+
+	// ...and dropped before the next append, so the segment stays readable
+	if err := s.Append("C1", []slack.Message{message("1700000060.000100", "second")}); err != nil {
+		t.Fatal(err)
+	}
+	msgs, err = s.LoadMonth("C1", "2023-11")
+	if err != nil {
+		t.Fatalf("LoadMonth after append: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].Text != "first" || msgs[1].Text != "second" {
+		t.Fatalf("LoadMonth after append = %+v; want first, second", msgs)
+	}
+}
+
+func TestAppendToSegmentWithoutNewline(t *testing.T) {
+	s := NewAt(t.TempDir())
+	path := s.segmentPath("C1", "2023-11")
+	if err := os.MkdirAll(s.Path("C1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing but a partial first record
+	if err := os.WriteFile(path, []byte(`{"message":{"ts":"1700`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Append("C1", []slack.Message{message("1700000000.000100", "first")}); err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := s.LoadMonth("C1", "2023-11")
+	if err != nil || len(msgs) != 1 || msgs[0].Text != "first" {
+		t.Fatalf("LoadMonth = %+v, %v; want first", msgs, err)
+	}
+}
+
+func TestLoadMonthSkipsTornRecord(t *testing.T) {
+	s := NewAt(t.TempDir())
+	if err := s.Append("C1", []slack.Message{message("1700000000.000100", "first")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Earlier versions appended onto a torn record, leaving it mid-segment
+	path := s.segmentPath("C1", "2023-11")
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"message":{"ts":"1700` + `{"message":{"ts":"1700000030.000100","text":"lost"}}` + "\n")
+	file.WriteString(`{"message":{"ts":"1700000060.000100","text":"third"}}` + "\n")
+	file.Close()
+
+	msgs, err := s.LoadMonth("C1", "2023-11")
+	if err != nil {
+		t.Fatalf("LoadMonth: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].Text != "first" || msgs[1].Text != "third" {
+		t.Fatalf("LoadMonth = %+v; want first, third", msgs)
+	}
+}
+
+func TestMigrateSkipsTornRecord(t *testing.T) {
+	s := NewAt(t.TempDir())
+	legacy := `{"message":{"ts":"1700000000.000100","text":"first"}}` + "\n" +
+		`{"message":{"ts":"17` + "\n" +
+		`{"message":{"ts":"1702000000.000100","text":"december"}}` + "\n"
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.legacyPath("C1"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.migrate("C1"); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	months, err := s.Months("C1")
+	if err != nil || len(months) != 2 {
+		t.Fatalf("Months = %v, %v; want 2023-11 and 2023-12", months, err)
+	}
+	msgs, err := s.LoadMonth("C1", "2023-12")
+	if err != nil || len(msgs) != 1 || msgs[0].Text != "december" {
+		t.Fatalf("LoadMonth(2023-12) = %+v, %v; want december", msgs, err)
+	}
+}
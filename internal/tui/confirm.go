@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,6 +9,7 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/chanseok/slackExtract/internal/downloader"
 	"github.com/chanseok/slackExtract/internal/manager"
 )
 
@@ -95,7 +97,18 @@ func (m Model) renderConfirmView() string {
 		s += fmt.Sprintf("✅ %d channels selected. No conflicts found.\n\n", len(m.Selected))
 	}
 
-	// 3. Action Selection
+	// 3. Date Range
+	if m.RangeMode {
+		s += fmt.Sprintf("📅 Date Range (SINCE..UNTIL, e.g. 30d or 2025-07-01..2025-09-30): %s█\n", m.RangeInput)
+		if m.RangeErr != "" {
+			s += fmt.Sprintf("   ⚠️  %s\n", m.RangeErr)
+		}
+		s += HelpStyle.Render("   Enter: Apply | Empty: All history | Esc: Cancel") + "\n\n"
+		return s
+	}
+	s += fmt.Sprintf("📅 Date Range: %s\n\n", m.DateRange)
+
+	// 4. Action Selection
 	s += "Choose Action:\n"
 	
 	// Adjust cursor base for actions (it starts after folders)
@@ -113,7 +126,7 @@ func (m Model) renderConfirmView() string {
 	s += "  [o] Overwrite all\n"
 	s += "  [c] Cancel\n"
 	
-	s += "\n" + HelpStyle.Render("↑↓: Select Folder | s/i/o: Start Download | c: Cancel")
+	s += "\n" + HelpStyle.Render("↑↓: Select Folder | r: Date Range | s/i/o: Start Download | c: Cancel")
 	
 	return s
 }
@@ -174,9 +187,17 @@ func (m *Model) scanForExistingFiles() {
 }
 
 func (m Model) updateConfirm(msg tea.Msg) (Model, tea.Cmd) {
+	if m.RangeMode {
+		return m.updateRangeInput(msg), nil
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
+		case "r":
+			m.RangeMode = true
+			m.RangeErr = ""
+			return m, nil
 		case "up", "k":
 			if m.FolderCursor > 0 {
 				m.FolderCursor--
@@ -202,12 +223,44 @@ func (m Model) updateConfirm(msg tea.Msg) (Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateRangeInput handles typing the date range on the confirm screen
+func (m Model) updateRangeInput(msg tea.Msg) Model {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m
+	}
+	switch keyMsg.String() {
+	case "esc":
+		m.RangeMode = false
+		m.RangeErr = ""
+	case "enter":
+		dateRange, err := downloader.ParseDateRangeSpec(m.RangeInput, time.Now())
+		if err != nil {
+			m.RangeErr = err.Error()
+			return m
+		}
+		m.DateRange = dateRange
+		m.RangeMode = false
+		m.RangeErr = ""
+	case "backspace":
+		if len(m.RangeInput) > 0 {
+			m.RangeInput = m.RangeInput[:len(m.RangeInput)-1]
+		}
+	default:
+		if len(keyMsg.String()) == 1 {
+			m.RangeInput += keyMsg.String()
+		}
+	}
+	return m
+}
+
 func (m Model) startDownloadSequence() (Model, tea.Cmd) {
 	m.ConfirmMode = false
 	m.IsDownloading = true
 	m.StartTime = time.Now()
 	m.TotalSelected = len(m.Selected)
 	m.ProgressChannel = make(chan ProgressMsg)
+	m.InFlight = make(map[string]ProgressMsg)
 	
 	// Set target folder based on selection
 	selectedFolder := m.SubFolders[m.FolderCursor]
@@ -221,8 +274,11 @@ func (m Model) startDownloadSequence() (Model, tea.Cmd) {
 		m.TargetFolder = filepath.Join("export", selectedFolder)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	m.CancelDownload = cancel
+
 	return m, tea.Batch(
-		startDownload(m),
+		startDownload(ctx, m),
 		waitForUpdate(m.ProgressChannel),
 	)
 }
@@ -1,12 +1,10 @@
 package tui
 
 import (
-	"fmt"
-	"time"
+	"context"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/chanseok/slackExtract/internal/export"
-	"github.com/chanseok/slackExtract/internal/slack"
+	"github.com/chanseok/slackExtract/internal/downloader"
 )
 
 func waitForUpdate(sub chan ProgressMsg) tea.Cmd {
@@ -15,16 +13,19 @@ func waitForUpdate(sub chan ProgressMsg) tea.Cmd {
 	}
 }
 
-func startDownload(m Model) tea.Cmd {
+func startDownload(ctx context.Context, m Model) tea.Cmd {
 	return func() tea.Msg {
 		go func() {
 			defer close(m.ProgressChannel)
 
-			currentChannelIdx := 0
+			d := downloader.New(m.SlackClient, m.HTTPClient, m.Users, m.Config, m.MetaManager)
+			d.TargetFolder = m.TargetFolder
+			d.Action = m.DownloadAction
+			d.ExistingFiles = m.ExistingFiles
+			d.Range = m.DateRange
 
+			var targets []downloader.Target
 			for channelID := range m.Selected {
-				currentChannelIdx++
-				
 				// Find channel name
 				channelName := channelID
 				for _, ch := range m.Channels {
@@ -33,113 +34,20 @@ func startDownload(m Model) tea.Cmd {
 						break
 					}
 				}
+				targets = append(targets, downloader.Target{ID: channelID, Name: channelName})
+			}
 
-				// Report start of channel
-				m.ProgressChannel <- ProgressMsg{
-					ChannelName: channelName,
-					Current:     0,
-					Total:       0,
-					Status:      "Starting...",
-					Done:        false,
-					AllDone:     false,
-				}
-
-				// Check existing file action
-				if m.DownloadAction == "skip" {
-					if _, exists := m.ExistingFiles[channelName]; exists {
-						m.ProgressChannel <- ProgressMsg{
-							ChannelName: channelName,
-							Status:      "Skipped (Already exists)",
-							Done:        true,
-						}
-						time.Sleep(200 * time.Millisecond)
-						continue
-					}
-				}
-
-				// Determine oldest timestamp for incremental download
-				var oldest string
-				if m.DownloadAction == "incremental" {
-					if meta, exists := m.ExistingFiles[channelName]; exists {
-						if !meta.LastMessageTime.IsZero() {
-							oldest = fmt.Sprintf("%d.000000", meta.LastMessageTime.Unix())
-							m.ProgressChannel <- ProgressMsg{
-								ChannelName: channelName,
-								Status:      fmt.Sprintf("Incremental from %s...", meta.LastMessageTime.Format("2006-01-02")),
-								Done:        false,
-							}
-						}
-					}
-				}
-
-				// Fetch History with retry support
-				retryCfg := slack.DefaultRetryConfig()
-				msgs, err := slack.FetchHistoryWithRetryAndProgress(m.SlackClient, channelID, retryCfg, func(current, total int, status string) {
-					m.ProgressChannel <- ProgressMsg{
-						ChannelName: channelName,
-						Current:     current,
-						Total:       total,
-						Status:      status,
-						Done:        false,
-						AllDone:     false,
-					}
-				}, oldest) // Pass oldest timestamp
-
-				if err != nil {
-					m.ProgressChannel <- ProgressMsg{
-						ChannelName: channelName,
-						Err:         fmt.Errorf("failed to fetch history: %w", err),
-						Done:        true,
-					}
-					continue
-				}
-
-				// Save to Markdown
+			d.DownloadAll(ctx, targets, m.Config.DownloadWorkers, func(e downloader.Event) {
 				m.ProgressChannel <- ProgressMsg{
-					ChannelName: channelName,
-					Current:     len(msgs),
-					Total:       len(msgs),
-					Status:      "Saving to Markdown & Downloading files...",
-					Done:        false,
+					ChannelID:   e.ChannelID,
+					ChannelName: e.ChannelName,
+					Current:     e.Current,
+					Total:       e.Total,
+					Status:      e.Status,
+					Done:        e.Done,
+					Err:         e.Err,
 				}
-
-				// Use TargetFolder from model
-				err = export.SaveToMarkdown(m.HTTPClient, channelName, msgs, m.UserMap, m.Config.DownloadAttachments, m.TargetFolder, m.DownloadAction == "incremental")
-				if err != nil {
-					m.ProgressChannel <- ProgressMsg{
-						ChannelName: channelName,
-						Err:         fmt.Errorf("failed to save: %w", err),
-						Done:        true,
-					}
-				} else {
-					// Update Metadata
-					if m.MetaManager != nil && len(msgs) > 0 {
-						lastMsg := msgs[len(msgs)-1]
-						lastMsgTime, _ := slack.ParseTimestamp(lastMsg.Timestamp)
-						
-						// Determine relative path (simplified for now, assumes standard structure)
-						relPath := fmt.Sprintf("%s.md", channelName)
-						if m.TargetFolder != "export" {
-							// If subfolder is used, we might need to adjust this logic
-							// For now, let's just store the channel name as ID reference
-						}
-
-						m.MetaManager.UpdateChannelDownload(channelID, channelName, relPath, len(msgs), lastMsgTime)
-						m.MetaManager.SaveIndex()
-					}
-
-					m.ProgressChannel <- ProgressMsg{
-						ChannelName: channelName,
-						Current:     len(msgs),
-						Total:       len(msgs),
-						Status:      "Done",
-						Done:        true,
-					}
-				}
-				
-				// Small pause to let the user see "Done"
-				time.Sleep(500 * time.Millisecond)
-			}
+			})
 
 			// All done
 			m.ProgressChannel <- ProgressMsg{
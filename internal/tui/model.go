@@ -1,21 +1,26 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/chanseok/slackExtract/internal/config"
+	"github.com/chanseok/slackExtract/internal/downloader"
 	"github.com/chanseok/slackExtract/internal/manager"
 	"github.com/chanseok/slackExtract/internal/meta"
+	islack "github.com/chanseok/slackExtract/internal/slack"
 	"github.com/slack-go/slack"
 )
 
 // ProgressMsg is sent by the worker to update the UI
 type ProgressMsg struct {
+	ChannelID   string
 	ChannelName string
 	Current     int    // Number of items processed (messages fetched, files downloaded)
 	Total       int    // Total items to process (if known)
@@ -57,6 +62,10 @@ type Model struct {
 	ExistingFiles  map[string]manager.ChannelMeta
 	DownloadAction string // "skip", "incremental", "overwrite"
 	ActionCursor   int    // 0: Skip, 1: Incremental, 2: Overwrite, 3: Cancel
+	DateRange      downloader.DateRange
+	RangeMode      bool   // Typing a date range on the confirm screen
+	RangeInput     string // "SINCE..UNTIL", e.g. "30d" or "2025-07-01..2025-09-30"
+	RangeErr       string
 
 	// Metadata Manager
 	MetaManager    *meta.Manager
@@ -64,20 +73,21 @@ type Model struct {
 	// Progress / Download State
 	SlackClient      *slack.Client
 	HTTPClient       *http.Client
-	UserMap          map[string]string
+	Users            *islack.UserDirectory
 	Config           *config.Config
 	IsDownloading    bool
-	ProgressChannel  chan ProgressMsg // Channel to receive updates from worker
-	CurrentChannel   string
-	ProgressCurrent  int
-	ProgressTotal    int
-	ProgressStatus   string
+	CancelDownload   context.CancelFunc // Stops the running download
+	Cancelling       bool               // Cancel requested; waiting for workers to stop
+	ProgressChannel  chan ProgressMsg      // Channel to receive updates from workers
+	InFlight         map[string]ProgressMsg // Latest progress of each channel being downloaded, keyed by channel ID
+	LastFinished     ProgressMsg
 	StartTime        time.Time
 	FinishedChannels int
+	FailedChannels   int
 	TotalSelected    int
 }
 
-func NewModel(channels []slack.Channel, client *slack.Client, httpClient *http.Client, userMap map[string]string, cfg *config.Config, metaManager *meta.Manager) Model {
+func NewModel(channels []slack.Channel, client *slack.Client, httpClient *http.Client, users *islack.UserDirectory, cfg *config.Config, metaManager *meta.Manager) Model {
 	m := Model{
 		Channels:       channels,
 		Selected:       make(map[string]struct{}),
@@ -87,7 +97,7 @@ func NewModel(channels []slack.Channel, client *slack.Client, httpClient *http.C
 		ShowDMs:        true,
 		SlackClient:    client,
 		HTTPClient:     httpClient,
-		UserMap:        userMap,
+		Users:          users,
 		Config:         cfg,
 		TargetFolder:   "export",
 		DownloadAction: "skip",
@@ -108,6 +118,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return newModel, cmd
 	}
 
+	// While downloading, keys only cancel; quitting right away would leave
+	// workers writing files in the background
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.IsDownloading {
+		switch keyMsg.String() {
+		case "c", "esc", "q", "ctrl+c":
+			if m.Cancelling && keyMsg.String() == "ctrl+c" {
+				// Second Ctrl+C: stop waiting
+				m.Quitting = true
+				return m, tea.Quit
+			}
+			if !m.Cancelling && m.CancelDownload != nil {
+				m.Cancelling = true
+				m.CancelDownload()
+			}
+		}
+		return m, nil
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.Height = msg.Height - 5 // Reserve lines for header/footer
@@ -250,12 +278,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.Quitting = true
 			return m, tea.Quit
 		}
-		m.CurrentChannel = msg.ChannelName
-		m.ProgressCurrent = msg.Current
-		m.ProgressTotal = msg.Total
-		m.ProgressStatus = msg.Status
 		if msg.Done {
+			delete(m.InFlight, msg.ChannelID)
+			m.LastFinished = msg
 			m.FinishedChannels++
+			if msg.Err != nil {
+				m.FailedChannels++
+			}
+		} else {
+			m.InFlight[msg.ChannelID] = msg
 		}
 		return m, waitForUpdate(m.ProgressChannel)
 	}
@@ -473,6 +504,9 @@ func (m Model) View() string {
 				if !chMeta.LastDownloadedAt.IsZero() {
 					metaStatus += " ⬇️ "
 				}
+				if chMeta.Partial != nil {
+					metaStatus += " ⏸️ "
+				}
 				if chMeta.Analysis != nil && !chMeta.Analysis.LastAnalyzedAt.IsZero() {
 					metaStatus += " 📝"
 				}
@@ -568,12 +602,39 @@ func (m Model) renderProgress() string {
 	s += fmt.Sprintf("  [%s] %.0f%%\n", bar, percent*100)
 	s += fmt.Sprintf("  %s\n\n", eta)
 
-	s += fmt.Sprintf("  Current: %s\n", m.CurrentChannel)
-	s += fmt.Sprintf("  Status:  %s\n", m.ProgressStatus)
-	if m.ProgressTotal > 0 {
-		s += fmt.Sprintf("  Items:   %d / %d\n", m.ProgressCurrent, m.ProgressTotal)
+	// In-flight channels, sorted by name for a stable display
+	inFlight := make([]ProgressMsg, 0, len(m.InFlight))
+	for _, p := range m.InFlight {
+		inFlight = append(inFlight, p)
+	}
+	sort.Slice(inFlight, func(i, j int) bool {
+		return inFlight[i].ChannelName < inFlight[j].ChannelName
+	})
+
+	s += fmt.Sprintf("  In progress (%d):\n", len(inFlight))
+	for _, p := range inFlight {
+		items := fmt.Sprintf("%d", p.Current)
+		if p.Total > 0 {
+			items = fmt.Sprintf("%d / %d", p.Current, p.Total)
+		}
+		s += fmt.Sprintf("  • %-24s %s [%s]\n", p.ChannelName, p.Status, items)
+	}
+
+	if m.LastFinished.ChannelName != "" {
+		status := m.LastFinished.Status
+		if m.LastFinished.Err != nil {
+			status = fmt.Sprintf("Error: %v", m.LastFinished.Err)
+		}
+		s += fmt.Sprintf("\n  Last finished: %s (%s)\n", m.LastFinished.ChannelName, status)
+	}
+	if m.FailedChannels > 0 {
+		s += fmt.Sprintf("  Failed: %d\n", m.FailedChannels)
+	}
+
+	if m.Cancelling {
+		s += "\n  ⏹️  Cancelling... finishing files being written (Ctrl+C again to quit now)\n"
 	} else {
-		s += fmt.Sprintf("  Items:   %d\n", m.ProgressCurrent)
+		s += "\n  c: Cancel\n"
 	}
 
 	return s
@@ -0,0 +1,31 @@
+// Package version reports which build of slack-extract is running
+package version
+
+import "runtime/debug"
+
+// Version can be set at build time:
+//
+//	go build -ldflags "-X github.com/chanseok/slackExtract/internal/version.Version=v1.2.0" ./cmd/slack-extract
+//
+// Otherwise it is taken from the module or VCS information of the build.
+var Version = ""
+
+// String returns the version, e.g. "v1.2.0", "dev+3f5e5b6" or "dev"
+func String() string {
+	if Version != "" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" && len(s.Value) >= 7 {
+			return "dev+" + s.Value[:7]
+		}
+	}
+	return "dev"
+}